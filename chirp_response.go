@@ -0,0 +1,146 @@
+package main
+
+import (
+	"time"
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/kmilanbanda/chirpy/internal/auth"
+	"github.com/kmilanbanda/chirpy/internal/database"
+	"github.com/kmilanbanda/chirpy/internal/linkpreview"
+)
+
+type chirpMediaResponse struct {
+	ID		uuid.UUID	`json:"id"`
+	URL		string		`json:"url"`
+	ThumbnailURL	string		`json:"thumbnail_url"`
+	ContentType	string		`json:"content_type"`
+	Width		int32		`json:"width"`
+	Height		int32		`json:"height"`
+	AltText		string		`json:"alt_text"`
+	Blurhash	string		`json:"blurhash"`
+}
+
+// chirpResponse is a chirp as the API returns it: the chirp's own columns plus the
+// things stored alongside it. PublishAt is only set while a chirp is scheduled.
+// Clients show chirps with a ContentWarning collapsed behind it. Pinned is only set
+// on a user's chirp listing.
+type chirpResponse struct {
+	ID		uuid.UUID		`json:"id"`
+	CreatedAt	time.Time		`json:"created_at"`
+	UpdatedAt	time.Time		`json:"updated_at"`
+	Body		string			`json:"body"`
+	UserID		uuid.UUID		`json:"user_id"`
+	Visibility	string			`json:"visibility"`
+	ContentWarning	string			`json:"content_warning"`
+	PublishAt	*time.Time		`json:"publish_at,omitempty"`
+	Media		[]chirpMediaResponse	`json:"media"`
+	Card		*linkCard		`json:"card"`
+	Poll		*pollResponse		`json:"poll"`
+	Pinned		bool			`json:"pinned,omitempty"`
+}
+
+// optionalViewer returns the user making the request, if any. Reading chirps does
+// not require a token, but a token that is sent has to be valid. Queries that filter
+// by viewer take viewerID.UUID, which is the nil UUID for anonymous readers.
+func (cfg *apiConfig) optionalViewer(req *http.Request) (uuid.NullUUID, error) {
+	if req.Header.Get("Authorization") == "" {
+		return uuid.NullUUID{}, nil
+	}
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		return uuid.NullUUID{}, err
+	}
+	userID, err := auth.ValidateScopedJWT(token, cfg.secret, "chirps:read")
+	if err != nil {
+		return uuid.NullUUID{}, err
+	}
+	return uuid.NullUUID{UUID: userID, Valid: true}, nil
+}
+
+func (cfg *apiConfig) newChirpMediaResponse(media database.ChirpMedium) chirpMediaResponse {
+	return chirpMediaResponse{
+		ID:		media.ID,
+		URL:		cfg.blobStore.URL(media.BlobKey),
+		ThumbnailURL:	cfg.blobStore.URL(media.ThumbnailKey),
+		ContentType:	media.ContentType,
+		Width:		media.Width,
+		Height:		media.Height,
+		AltText:	media.AltText,
+		Blurhash:	media.Blurhash,
+	}
+}
+
+// chirpResponses loads the attachments, link cards and polls of every chirp with one
+// query each. Poll results depend on whether viewerID has voted.
+func (cfg *apiConfig) chirpResponses(ctx context.Context, viewerID uuid.NullUUID, chirps []database.Chirp) ([]chirpResponse, error) {
+	chirpIDs := make([]uuid.UUID, len(chirps))
+	for i, chirp := range chirps {
+		chirpIDs[i] = chirp.ID
+	}
+	media, err := cfg.db.GetMediaForChirps(ctx, chirpIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	cards, err := cfg.linkCards(ctx, chirps)
+	if err != nil {
+		return nil, err
+	}
+
+	polls, err := cfg.pollResponses(ctx, viewerID, chirpIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	mediaByChirp := map[uuid.UUID][]chirpMediaResponse{}
+	for _, m := range media {
+		mediaByChirp[m.ChirpID.UUID] = append(mediaByChirp[m.ChirpID.UUID], cfg.newChirpMediaResponse(m))
+	}
+
+	responses := make([]chirpResponse, len(chirps))
+	for i, chirp := range chirps {
+		responses[i] = chirpResponse{
+			ID:		chirp.ID,
+			CreatedAt:	chirp.CreatedAt,
+			UpdatedAt:	chirp.UpdatedAt,
+			Body:		chirp.Body,
+			UserID:		chirp.UserID,
+			Visibility:	chirp.Visibility,
+			ContentWarning:	chirp.ContentWarning,
+			Media:		mediaByChirp[chirp.ID],
+			Card:		cards[linkpreview.FirstURL(chirp.Body)],
+			Poll:		polls[chirp.ID],
+		}
+		if !chirp.PublishedAt.Valid && chirp.PublishAt.Valid {
+			publishAt := chirp.PublishAt.Time
+			responses[i].PublishAt = &publishAt
+		}
+		if responses[i].Media == nil {
+			responses[i].Media = []chirpMediaResponse{}
+		}
+	}
+	return responses, nil
+}
+
+func (cfg *apiConfig) chirpResponse(ctx context.Context, viewerID uuid.NullUUID, chirp database.Chirp) (chirpResponse, error) {
+	responses, err := cfg.chirpResponses(ctx, viewerID, []database.Chirp{chirp})
+	if err != nil {
+		return chirpResponse{}, err
+	}
+	return responses[0], nil
+}
+
+// deleteChirp deletes a chirp along with its attachments and their blobs
+func (cfg *apiConfig) deleteChirp(ctx context.Context, chirpID uuid.UUID) error {
+	media, err := cfg.db.GetMediaForChirps(ctx, []uuid.UUID{chirpID})
+	if err != nil {
+		return err
+	}
+	if err := cfg.db.DeleteChirp(ctx, chirpID); err != nil {
+		return err
+	}
+	cfg.deleteMediaBlobs(media)
+	return nil
+}
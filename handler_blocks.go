@@ -0,0 +1,238 @@
+package main
+
+import (
+	"time"
+	"context"
+	"net/http"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/kmilanbanda/chirpy/internal/auth"
+	"github.com/kmilanbanda/chirpy/internal/database"
+)
+
+// blockedUserResponse is one entry in the lists of blocked and muted users. Neither
+// list is visible to anyone but its owner.
+type blockedUserResponse struct {
+	UserID	uuid.UUID	`json:"user_id"`
+	Handle	string		`json:"handle"`
+	Since	time.Time	`json:"since"`
+}
+
+// handlerBlockUser blocks a user and removes any follows between the two accounts in
+// either direction. The blocked user can no longer see, follow or mention the blocker.
+func (cfg *apiConfig) handlerBlockUser(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	blocked, err := cfg.getUserByHandleOrID(context.Background(), req.PathValue("handleOrID"))
+	if err != nil {
+		handleErrorResponse(w, http.StatusNotFound, "Error finding user")
+		return
+	}
+	if blocked.ID == userID {
+		handleErrorResponse(w, http.StatusBadRequest, "You cannot block yourself")
+		return
+	}
+
+	createBlockParams := database.CreateBlockParams{
+		BlockerID:	userID,
+		BlockedID:	blocked.ID,
+	}
+	if err := cfg.db.CreateBlock(context.Background(), createBlockParams); err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error blocking user")
+		return
+	}
+
+	deleteFollowsBetweenParams := database.DeleteFollowsBetweenParams{
+		UserID:		userID,
+		OtherID:	blocked.ID,
+	}
+	if err := cfg.db.DeleteFollowsBetween(context.Background(), deleteFollowsBetweenParams); err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error removing follows")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerUnblockUser removes a block. Follows removed by the block are not restored.
+func (cfg *apiConfig) handlerUnblockUser(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	blocked, err := cfg.getUserByHandleOrID(context.Background(), req.PathValue("handleOrID"))
+	if err != nil {
+		handleErrorResponse(w, http.StatusNotFound, "Error finding user")
+		return
+	}
+
+	deleteBlockParams := database.DeleteBlockParams{
+		BlockerID:	userID,
+		BlockedID:	blocked.ID,
+	}
+	if err := cfg.db.DeleteBlock(context.Background(), deleteBlockParams); err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error unblocking user")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerGetBlocks(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	blocks, err := cfg.db.GetBlocks(context.Background(), userID)
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error getting blocked users")
+		return
+	}
+
+	resp := []blockedUserResponse{}
+	for _, block := range blocks {
+		resp = append(resp, blockedUserResponse{
+			UserID:	block.ID,
+			Handle:	block.Handle,
+			Since:	block.CreatedAt,
+		})
+	}
+
+	w.WriteHeader(http.StatusOK)
+	dat, _ := json.Marshal(resp)
+	w.Write(dat)
+}
+
+// handlerMuteUser hides a user's chirps from GET /api/chirps for the muter only. The
+// muted user is not told and nothing else about the relationship changes.
+func (cfg *apiConfig) handlerMuteUser(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	muted, err := cfg.getUserByHandleOrID(context.Background(), req.PathValue("handleOrID"))
+	if err != nil {
+		handleErrorResponse(w, http.StatusNotFound, "Error finding user")
+		return
+	}
+	if muted.ID == userID {
+		handleErrorResponse(w, http.StatusBadRequest, "You cannot mute yourself")
+		return
+	}
+
+	createMuteParams := database.CreateMuteParams{
+		MuterID:	userID,
+		MutedID:	muted.ID,
+	}
+	if err := cfg.db.CreateMute(context.Background(), createMuteParams); err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error muting user")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUnmuteUser(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	muted, err := cfg.getUserByHandleOrID(context.Background(), req.PathValue("handleOrID"))
+	if err != nil {
+		handleErrorResponse(w, http.StatusNotFound, "Error finding user")
+		return
+	}
+
+	deleteMuteParams := database.DeleteMuteParams{
+		MuterID:	userID,
+		MutedID:	muted.ID,
+	}
+	if err := cfg.db.DeleteMute(context.Background(), deleteMuteParams); err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error unmuting user")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerGetMutes(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	mutes, err := cfg.db.GetMutes(context.Background(), userID)
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error getting muted users")
+		return
+	}
+
+	resp := []blockedUserResponse{}
+	for _, mute := range mutes {
+		resp = append(resp, blockedUserResponse{
+			UserID:	mute.ID,
+			Handle:	mute.Handle,
+			Since:	mute.CreatedAt,
+		})
+	}
+
+	w.WriteHeader(http.StatusOK)
+	dat, _ := json.Marshal(resp)
+	w.Write(dat)
+}
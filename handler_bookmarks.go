@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/kmilanbanda/chirpy/internal/auth"
+	"github.com/kmilanbanda/chirpy/internal/database"
+)
+
+// handlerCreateBookmark bookmarks a chirp the user can see. Bookmarks are private:
+// the author is not told and they are not counted anywhere.
+func (cfg *apiConfig) handlerCreateBookmark(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateScopedJWT(token, cfg.secret, "chirps:write")
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	chirpID, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		handleErrorResponse(w, http.StatusNotFound, "Error finding chirp")
+		return
+	}
+
+	getChirpParams := database.GetChirpParams{
+		ID:		chirpID,
+		ViewerID:	userID,
+	}
+	chirp, err := cfg.db.GetChirp(context.Background(), getChirpParams)
+	if err != nil || !chirp.PublishedAt.Valid {
+		handleErrorResponse(w, http.StatusNotFound, "Error finding chirp")
+		return
+	}
+
+	createBookmarkParams := database.CreateBookmarkParams{
+		UserID:		userID,
+		ChirpID:	chirp.ID,
+	}
+	if err := cfg.db.CreateBookmark(context.Background(), createBookmarkParams); err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error bookmarking chirp")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerDeleteBookmark(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateScopedJWT(token, cfg.secret, "chirps:write")
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	chirpID, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		handleErrorResponse(w, http.StatusNotFound, "Error finding chirp")
+		return
+	}
+
+	deleteBookmarkParams := database.DeleteBookmarkParams{
+		UserID:		userID,
+		ChirpID:	chirpID,
+	}
+	if err := cfg.db.DeleteBookmark(context.Background(), deleteBookmarkParams); err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error removing bookmark")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerGetBookmarks pages through the user's bookmarks, most recently bookmarked
+// first. Chirps the user can no longer see are left out.
+func (cfg *apiConfig) handlerGetBookmarks(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateScopedJWT(token, cfg.secret, "chirps:read")
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	page, err := parsePageRequest(req.URL.Query())
+	if err != nil {
+		handleErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	getBookmarkedChirpsParams := database.GetBookmarkedChirpsParams{
+		UserID:			userID,
+		BeforeCreatedAt:	page.Before.CreatedAt,
+		BeforeID:		page.Before.ID,
+		RowLimit:		page.fetchLimit(),
+	}
+	rows, err := cfg.db.GetBookmarkedChirps(context.Background(), getBookmarkedChirpsParams)
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error getting bookmarks")
+		return
+	}
+
+	chirps := make([]database.Chirp, len(rows))
+	cursors := make([]pageCursor, len(rows))
+	for i, row := range rows {
+		chirps[i] = database.Chirp{
+			ID:		row.ID,
+			CreatedAt:	row.CreatedAt,
+			UpdatedAt:	row.UpdatedAt,
+			Body:		row.Body,
+			UserID:		row.UserID,
+			PublishAt:	row.PublishAt,
+			PublishedAt:	row.PublishedAt,
+			Visibility:	row.Visibility,
+			ContentWarning:	row.ContentWarning,
+			BodyWords:	row.BodyWords,
+			ContentWarningWords:	row.ContentWarningWords,
+		}
+		cursors[i] = pageCursor{CreatedAt: row.BookmarkedAt, ID: row.ID}
+	}
+
+	resp, err := cfg.chirpResponses(context.Background(), uuid.NullUUID{UUID: userID, Valid: true}, chirps)
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error getting chirp media")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	dat, _ := json.Marshal(newChirpPage(page, resp, cursors))
+	w.Write(dat)
+}
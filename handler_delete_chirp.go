@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"github.com/kmilanbanda/chirpy/internal/auth"
 	"github.com/google/uuid"
+	"github.com/kmilanbanda/chirpy/internal/database"
 )
 
 func (cfg *apiConfig) handlerDeleteChirp(w http.ResponseWriter, req *http.Request) {
@@ -13,7 +14,7 @@ func (cfg *apiConfig) handlerDeleteChirp(w http.ResponseWriter, req *http.Reques
 		handleErrorResponse(w, http.StatusUnauthorized, "Failed to read header")
 		return
 	}
-	validatedUserID, err := auth.ValidateJWT(token, cfg.secret)
+	validatedUserID, err := auth.ValidateScopedJWT(token, cfg.secret, "chirps:write")
 	if err != nil {
 		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
 		return
@@ -24,7 +25,11 @@ func (cfg *apiConfig) handlerDeleteChirp(w http.ResponseWriter, req *http.Reques
 		return
 	}
 
-	chirp, err := cfg.db.GetChirp(context.Background(), chirpID)
+	getChirpParams := database.GetChirpParams{
+		ID:		chirpID,
+		ViewerID:	validatedUserID,
+	}
+	chirp, err := cfg.db.GetChirp(context.Background(), getChirpParams)
 	if err != nil {
 		handleErrorResponse(w, http.StatusNotFound, "Error finding chirp")
 		return
@@ -35,6 +40,10 @@ func (cfg *apiConfig) handlerDeleteChirp(w http.ResponseWriter, req *http.Reques
 		return
 	}
 
-	cfg.db.DeleteChirp(context.Background(), chirpID)
+	// Deleting a pinned chirp also unpins it, through the users.pinned_chirp_id foreign key
+	if err := cfg.deleteChirp(context.Background(), chirpID); err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error deleting chirp")
+		return
+	}
 	w.WriteHeader(http.StatusNoContent)
 }
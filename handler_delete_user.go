@@ -0,0 +1,146 @@
+package main
+
+import (
+	"log"
+	"time"
+	"context"
+	"net/http"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/kmilanbanda/chirpy/internal/auth"
+	"github.com/kmilanbanda/chirpy/internal/database"
+)
+
+const accountDeletionPurgeInterval = time.Hour
+
+// handlerDeleteUser schedules the user's account for deletion after the grace period.
+// Every refresh token is revoked so other sessions end; logging in again cancels it.
+// Access tokens that were already issued stay valid until they expire, but
+// middlewarePendingDeletion stops them from changing anything in the meantime.
+func (cfg *apiConfig) handlerDeleteUser(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	type request struct {
+		Password	string	`json:"password"`
+	}
+
+	var reqBody request
+	if err := json.NewDecoder(req.Body).Decode(&reqBody); err != nil {
+		handleErrorResponse(w, http.StatusBadRequest, "Error decoding request parameters")
+		return
+	}
+
+	user, err := cfg.db.GetUserByID(context.Background(), userID)
+	if err != nil {
+		handleErrorResponse(w, http.StatusNotFound, "Error finding user")
+		return
+	}
+
+	if err := auth.CheckPasswordHash(user.HashedPassword, reqBody.Password); err != nil {
+		handleErrorResponse(w, http.StatusForbidden, "Password is incorrect")
+		return
+	}
+
+	scheduleUserDeletionParams := database.ScheduleUserDeletionParams{
+		ID:			userID,
+		DeletionScheduledFor:	sql.NullTime{Time: time.Now().Add(cfg.accountDeletionGracePeriod), Valid: true},
+	}
+	user, err = cfg.db.ScheduleUserDeletion(context.Background(), scheduleUserDeletionParams)
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error scheduling deletion")
+		return
+	}
+
+	if err := cfg.db.RevokeUserRefreshTokens(context.Background(), userID); err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error revoking refresh tokens")
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	resp := struct{
+		DeletionScheduledFor	time.Time	`json:"deletion_scheduled_for"`
+	}{
+		DeletionScheduledFor:	user.DeletionScheduledFor.Time,
+	}
+	dat, _ := json.Marshal(resp)
+	w.Write(dat)
+}
+
+// middlewarePendingDeletion rejects requests that would change something when they
+// are made with the access token of an account scheduled for deletion. Reading is
+// still allowed; logging in cancels the deletion and issues a token that works again.
+func (cfg *apiConfig) middlewarePendingDeletion(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodGet || req.Method == http.MethodHead || req.Method == http.MethodOptions {
+			next.ServeHTTP(w, req)
+			return
+		}
+		// Requests without an access token are left to the handler to authenticate
+		token, err := auth.GetBearerToken(req.Header)
+		if err != nil {
+			next.ServeHTTP(w, req)
+			return
+		}
+		_, userID, err := auth.ParseAccessToken(token, cfg.secret)
+		if err != nil {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		user, err := cfg.db.GetUserByID(context.Background(), userID)
+		if err == nil && user.DeletionScheduledFor.Valid {
+			w.Header().Set("Content-Type", "application/json")
+			handleErrorResponse(w, http.StatusForbidden, "Account is scheduled for deletion, log in again to cancel it")
+			return
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+// purgeDeletedAccounts deletes every account whose grace period is over until ctx is
+// done. Chirps, tokens and everything else owned by the user go with it through
+// ON DELETE CASCADE; their avatar, header and chirp images are removed from the blob store.
+func (cfg *apiConfig) purgeDeletedAccounts(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		// The media rows go with the users, so their blobs are looked up first
+		media, err := cfg.db.GetMediaForUsersScheduledForDeletion(ctx)
+		if err != nil {
+			log.Printf("Error finding media of deleted accounts: %v", err)
+		}
+
+		deleted, err := cfg.db.DeleteUsersScheduledForDeletion(ctx)
+		if err != nil {
+			log.Printf("Error deleting accounts: %v", err)
+		} else if len(deleted) > 0 {
+			log.Printf("Deleted %d accounts after their grace period", len(deleted))
+		}
+		for _, user := range deleted {
+			cfg.deleteBlob(user.AvatarKey)
+			cfg.deleteBlob(user.HeaderKey)
+		}
+		if err == nil {
+			cfg.deleteMediaBlobs(media)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
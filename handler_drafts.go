@@ -0,0 +1,364 @@
+package main
+
+import (
+	"fmt"
+	"time"
+	"context"
+	"net/http"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/kmilanbanda/chirpy/internal/auth"
+	"github.com/kmilanbanda/chirpy/internal/database"
+)
+
+const maxDraftsPerUser = 100
+
+type draftResponse struct {
+	ID		uuid.UUID	`json:"id"`
+	CreatedAt	time.Time	`json:"created_at"`
+	UpdatedAt	time.Time	`json:"updated_at"`
+	Body		string		`json:"body"`
+	MediaIDs	[]uuid.UUID	`json:"media_ids"`
+	Poll		*pollRequest	`json:"poll"`
+	PublishAt	*time.Time	`json:"publish_at"`
+	Visibility	string		`json:"visibility"`
+	ContentWarning	string		`json:"content_warning"`
+}
+
+func draftInput(draft database.Draft) chirpInput {
+	input := chirpInput{
+		Body:		draft.Body,
+		MediaIDs:	draft.MediaIds,
+		Visibility:	draft.Visibility,
+		ContentWarning:	draft.ContentWarning,
+	}
+	if draft.PollOptions != nil {
+		input.Poll = &pollRequest{
+			Options:	draft.PollOptions,
+			ClosesAt:	draft.PollClosesAt.Time,
+		}
+	}
+	if draft.PublishAt.Valid {
+		input.PublishAt = &draft.PublishAt.Time
+	}
+	return input
+}
+
+func newDraftResponse(draft database.Draft) draftResponse {
+	input := draftInput(draft)
+	resp := draftResponse{
+		ID:		draft.ID,
+		CreatedAt:	draft.CreatedAt,
+		UpdatedAt:	draft.UpdatedAt,
+		Body:		input.Body,
+		MediaIDs:	input.MediaIDs,
+		Poll:		input.Poll,
+		PublishAt:	input.PublishAt,
+		Visibility:	input.Visibility,
+		ContentWarning:	input.ContentWarning,
+	}
+	if resp.MediaIDs == nil {
+		resp.MediaIDs = []uuid.UUID{}
+	}
+	return resp
+}
+
+// checkDraft only enforces size limits and media ownership. Drafts can be unfinished,
+// so the rest of the validation waits until the draft is published.
+func (cfg *apiConfig) checkDraft(ctx context.Context, userID uuid.UUID, input chirpInput) error {
+	if len(input.Body) > cfg.maxChirpLength {
+		return fmt.Errorf("Chirp is too long")
+	}
+	if err := validateProfileField("Content warning", input.ContentWarning, maxContentWarningLength); err != nil {
+		return err
+	}
+	if _, ok := chirpVisibilities[input.Visibility]; !ok && input.Visibility != "" {
+		return fmt.Errorf("Visibility must be \"public\", \"unlisted\", \"followers\" or \"mentioned\"")
+	}
+	if input.Poll != nil && len(input.Poll.Options) > maxPollOptions {
+		return fmt.Errorf("A poll must have %d to %d options", minPollOptions, maxPollOptions)
+	}
+	return cfg.checkChirpMedia(ctx, userID, input.MediaIDs)
+}
+
+// draftColumns converts a chirpInput into the nullable columns drafts are stored in
+func draftColumns(input chirpInput) (pollOptions []string, pollClosesAt, publishAt sql.NullTime) {
+	if input.Poll != nil {
+		pollOptions = input.Poll.Options
+		if pollOptions == nil {
+			pollOptions = []string{}
+		}
+		pollClosesAt = sql.NullTime{Time: input.Poll.ClosesAt.UTC(), Valid: !input.Poll.ClosesAt.IsZero()}
+	}
+	if input.PublishAt != nil {
+		publishAt = sql.NullTime{Time: input.PublishAt.UTC(), Valid: true}
+	}
+	return pollOptions, pollClosesAt, publishAt
+}
+
+// getOwnDraft returns the draft if it belongs to userID. Other users' drafts are
+// reported as not found.
+func (cfg *apiConfig) getOwnDraft(ctx context.Context, userID uuid.UUID, draftIDString string) (database.Draft, error) {
+	draftID, err := uuid.Parse(draftIDString)
+	if err != nil {
+		return database.Draft{}, err
+	}
+	draft, err := cfg.db.GetDraft(ctx, draftID)
+	if err != nil {
+		return database.Draft{}, err
+	}
+	if draft.UserID != userID {
+		return database.Draft{}, sql.ErrNoRows
+	}
+	return draft, nil
+}
+
+func (cfg *apiConfig) handlerCreateDraft(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateScopedJWT(token, cfg.secret, "chirps:write")
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	var reqBody chirpInput
+	if err := json.NewDecoder(req.Body).Decode(&reqBody); err != nil {
+		handleErrorResponse(w, http.StatusBadRequest, "Error decoding request parameters")
+		return
+	}
+	if err := cfg.checkDraft(context.Background(), userID, reqBody); err != nil {
+		handleErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	count, err := cfg.db.CountDraftsByUser(context.Background(), userID)
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error counting drafts")
+		return
+	}
+	if count >= maxDraftsPerUser {
+		handleErrorResponse(w, http.StatusConflict, fmt.Sprintf("You can have at most %d drafts", maxDraftsPerUser))
+		return
+	}
+
+	pollOptions, pollClosesAt, publishAt := draftColumns(reqBody)
+	createDraftParams := database.CreateDraftParams{
+		UserID:		userID,
+		Body:		reqBody.Body,
+		MediaIds:	reqBody.MediaIDs,
+		PollOptions:	pollOptions,
+		PollClosesAt:	pollClosesAt,
+		PublishAt:	publishAt,
+		Visibility:	reqBody.Visibility,
+		ContentWarning:	reqBody.ContentWarning,
+	}
+	if createDraftParams.Visibility == "" {
+		createDraftParams.Visibility = "public"
+	}
+	if createDraftParams.MediaIds == nil {
+		createDraftParams.MediaIds = []uuid.UUID{}
+	}
+	draft, err := cfg.db.CreateDraft(context.Background(), createDraftParams)
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error saving draft")
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	dat, _ := json.Marshal(newDraftResponse(draft))
+	w.Write(dat)
+}
+
+func (cfg *apiConfig) handlerGetDrafts(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateScopedJWT(token, cfg.secret, "chirps:read")
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	drafts, err := cfg.db.GetDraftsByUser(context.Background(), userID)
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error getting drafts")
+		return
+	}
+
+	resp := []draftResponse{}
+	for _, draft := range drafts {
+		resp = append(resp, newDraftResponse(draft))
+	}
+
+	w.WriteHeader(http.StatusOK)
+	dat, _ := json.Marshal(resp)
+	w.Write(dat)
+}
+
+func (cfg *apiConfig) handlerGetDraft(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateScopedJWT(token, cfg.secret, "chirps:read")
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	draft, err := cfg.getOwnDraft(context.Background(), userID, req.PathValue("draftID"))
+	if err != nil {
+		handleErrorResponse(w, http.StatusNotFound, "Error finding draft")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	dat, _ := json.Marshal(newDraftResponse(draft))
+	w.Write(dat)
+}
+
+// handlerUpdateDraft replaces the draft's contents with the request body
+func (cfg *apiConfig) handlerUpdateDraft(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateScopedJWT(token, cfg.secret, "chirps:write")
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	draft, err := cfg.getOwnDraft(context.Background(), userID, req.PathValue("draftID"))
+	if err != nil {
+		handleErrorResponse(w, http.StatusNotFound, "Error finding draft")
+		return
+	}
+
+	var reqBody chirpInput
+	if err := json.NewDecoder(req.Body).Decode(&reqBody); err != nil {
+		handleErrorResponse(w, http.StatusBadRequest, "Error decoding request parameters")
+		return
+	}
+	if err := cfg.checkDraft(context.Background(), userID, reqBody); err != nil {
+		handleErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	pollOptions, pollClosesAt, publishAt := draftColumns(reqBody)
+	updateDraftParams := database.UpdateDraftParams{
+		ID:		draft.ID,
+		Body:		reqBody.Body,
+		MediaIds:	reqBody.MediaIDs,
+		PollOptions:	pollOptions,
+		PollClosesAt:	pollClosesAt,
+		PublishAt:	publishAt,
+		Visibility:	reqBody.Visibility,
+		ContentWarning:	reqBody.ContentWarning,
+	}
+	if updateDraftParams.Visibility == "" {
+		updateDraftParams.Visibility = "public"
+	}
+	if updateDraftParams.MediaIds == nil {
+		updateDraftParams.MediaIds = []uuid.UUID{}
+	}
+	draft, err = cfg.db.UpdateDraft(context.Background(), updateDraftParams)
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error saving draft")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	dat, _ := json.Marshal(newDraftResponse(draft))
+	w.Write(dat)
+}
+
+func (cfg *apiConfig) handlerDeleteDraft(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateScopedJWT(token, cfg.secret, "chirps:write")
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	draft, err := cfg.getOwnDraft(context.Background(), userID, req.PathValue("draftID"))
+	if err != nil {
+		handleErrorResponse(w, http.StatusNotFound, "Error finding draft")
+		return
+	}
+
+	if err := cfg.db.DeleteDraft(context.Background(), draft.ID); err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error deleting draft")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerPublishDraft posts the draft through the same pipeline as POST /api/chirps
+// and deletes it once the chirp exists. A draft that fails validation is kept.
+func (cfg *apiConfig) handlerPublishDraft(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateScopedJWT(token, cfg.secret, "chirps:write")
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	draft, err := cfg.getOwnDraft(context.Background(), userID, req.PathValue("draftID"))
+	if err != nil {
+		handleErrorResponse(w, http.StatusNotFound, "Error finding draft")
+		return
+	}
+
+	chirp, status, err := cfg.publishChirp(context.Background(), userID, draftInput(draft))
+	if err != nil {
+		handleErrorResponse(w, status, err.Error())
+		return
+	}
+
+	if err := cfg.db.DeleteDraft(context.Background(), draft.ID); err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error deleting draft")
+		return
+	}
+
+	resp, err := cfg.chirpResponse(context.Background(), uuid.NullUUID{UUID: userID, Valid: true}, chirp)
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error getting chirp media")
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	dat, _ := json.Marshal(resp)
+	w.Write(dat)
+}
@@ -1,8 +1,12 @@
 package main
 
 import (
+	"errors"
 	"encoding/json"
 	"net/http"
+
+	"github.com/lib/pq"
+	"github.com/kmilanbanda/chirpy/internal/auth"
 )
 
 func handleErrorResponse(w http.ResponseWriter, httpStatusCode int, errorMessage string) {
@@ -11,3 +15,53 @@ func handleErrorResponse(w http.ResponseWriter, httpStatusCode int, errorMessage
 	dat, _ := json.Marshal(resp)
 	w.Write(dat)	
 }
+
+// Names of the UNIQUE constraints on users, as reported by postgres
+const (
+	usersEmailConstraint	= "users_email_key"
+	usersHandleConstraint	= "users_handle_key"
+)
+
+// isUniqueViolation reports whether err was caused by the named UNIQUE constraint in postgres
+func isUniqueViolation(err error, constraint string) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == constraint
+}
+
+func handlePasswordPolicyError(w http.ResponseWriter, err error) {
+	var policyErr *auth.PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error validating password")
+		return
+	}
+
+	w.WriteHeader(http.StatusBadRequest)
+	resp := struct{
+		Error	string		`json:"error"`
+		Details	[]string	`json:"details"`
+	}{
+		Error:		"Password does not meet the password policy",
+		Details:	policyErr.Problems,
+	}
+	dat, _ := json.Marshal(resp)
+	w.Write(dat)
+}
+
+// handleOAuthErrorResponse writes an error in the RFC 6749 format that OAuth client libraries expect
+func handleOAuthErrorResponse(w http.ResponseWriter, httpStatusCode int, errorCode, description string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if httpStatusCode == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Basic realm="chirpy"`)
+	}
+	w.WriteHeader(httpStatusCode)
+	resp := struct{
+		Error			string	`json:"error"`
+		ErrorDescription	string	`json:"error_description,omitempty"`
+	}{
+		Error:			errorCode,
+		ErrorDescription:	description,
+	}
+	dat, _ := json.Marshal(resp)
+	w.Write(dat)
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"log"
+	"fmt"
+	"time"
+	"bytes"
+	"context"
+	"net/url"
+	"net/http"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/kmilanbanda/chirpy/internal/auth"
+	"github.com/kmilanbanda/chirpy/internal/mailer"
+	"github.com/kmilanbanda/chirpy/internal/archive"
+	"github.com/kmilanbanda/chirpy/internal/database"
+)
+
+const (
+	dataExportDuration	= time.Hour * 24 * 7
+	// dataExportTimeout is how long an export may stay pending before it is reported as
+	// failed, which happens if the server restarts while building it
+	dataExportTimeout	= time.Hour
+)
+
+type dataExportResponse struct {
+	ID		uuid.UUID	`json:"id"`
+	CreatedAt	time.Time	`json:"created_at"`
+	Status		string		`json:"status"`
+	ExpiresAt	*time.Time	`json:"expires_at,omitempty"`
+	DownloadURL	string		`json:"download_url,omitempty"`
+}
+
+func (cfg *apiConfig) newDataExportResponse(export database.DataExport) dataExportResponse {
+	resp := dataExportResponse{
+		ID:		export.ID,
+		CreatedAt:	export.CreatedAt,
+		Status:		export.Status,
+	}
+	if export.Status == "pending" && time.Since(export.CreatedAt) > dataExportTimeout {
+		resp.Status = "failed"
+	}
+	if export.Status == "ready" {
+		resp.ExpiresAt = &export.ExpiresAt.Time
+		resp.DownloadURL = cfg.dataExportDownloadURL(export.DownloadToken.String)
+	}
+	return resp
+}
+
+func (cfg *apiConfig) dataExportDownloadURL(token string) string {
+	return fmt.Sprintf("%s/api/exports/download?token=%s", cfg.baseURL, url.QueryEscape(token))
+}
+
+// handlerCreateDataExport starts building an archive of the user's data in the
+// background. Asking again while one is pending returns the pending export.
+func (cfg *apiConfig) handlerCreateDataExport(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	export, err := cfg.db.GetPendingDataExportByUser(context.Background(), userID)
+	if err == nil {
+		w.WriteHeader(http.StatusAccepted)
+		dat, _ := json.Marshal(cfg.newDataExportResponse(export))
+		w.Write(dat)
+		return
+	}
+
+	if err := cfg.db.DeleteExpiredDataExports(context.Background()); err != nil {
+		log.Printf("Error deleting expired data exports: %v", err)
+	}
+
+	export, err = cfg.db.CreateDataExport(context.Background(), userID)
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error creating data export")
+		return
+	}
+
+	go cfg.buildDataExport(export.ID, userID)
+
+	w.WriteHeader(http.StatusAccepted)
+	dat, _ := json.Marshal(cfg.newDataExportResponse(export))
+	w.Write(dat)
+}
+
+func (cfg *apiConfig) handlerGetDataExport(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	exportID, err := uuid.Parse(req.PathValue("exportID"))
+	if err != nil {
+		handleErrorResponse(w, http.StatusNotFound, "Error parsing export ID")
+		return
+	}
+
+	export, err := cfg.db.GetDataExport(context.Background(), exportID)
+	if err != nil || export.UserID != userID {
+		handleErrorResponse(w, http.StatusNotFound, "Error finding data export")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	dat, _ := json.Marshal(cfg.newDataExportResponse(export))
+	w.Write(dat)
+}
+
+// handlerDownloadDataExport serves the archive to anyone holding the link, so the link
+// can be opened from the email without logging in
+func (cfg *apiConfig) handlerDownloadDataExport(w http.ResponseWriter, req *http.Request) {
+	token := req.URL.Query().Get("token")
+	if token == "" {
+		w.Header().Set("Content-Type", "application/json")
+		handleErrorResponse(w, http.StatusBadRequest, "Missing download token")
+		return
+	}
+
+	export, err := cfg.db.GetDataExportByDownloadToken(context.Background(), sql.NullString{String: token, Valid: true})
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		handleErrorResponse(w, http.StatusNotFound, "Unable to find data export")
+		return
+	} else if time.Now().After(export.ExpiresAt.Time) {
+		w.Header().Set("Content-Type", "application/json")
+		handleErrorResponse(w, http.StatusGone, "Download link expired")
+		return
+	}
+
+	filename := fmt.Sprintf("chirpy-export-%s.zip", export.CreatedAt.Format("2006-01-02"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Header().Set("Cache-Control", "private, no-store")
+	http.ServeContent(w, req, filename, export.UpdatedAt, bytes.NewReader(export.Archive))
+}
+
+// buildDataExport collects the user's data, stores the archive and emails the link
+func (cfg *apiConfig) buildDataExport(exportID, userID uuid.UUID) {
+	ctx := context.Background()
+
+	var buf bytes.Buffer
+	err := cfg.collectDataExport(ctx, userID, &buf)
+	if err != nil {
+		log.Printf("Error building data export %s: %v", exportID, err)
+		if err := cfg.db.FailDataExport(ctx, exportID); err != nil {
+			log.Printf("Error marking data export %s as failed: %v", exportID, err)
+		}
+		return
+	}
+
+	downloadToken, err := auth.MakeRefreshToken()
+	if err != nil {
+		log.Printf("Error making download token for data export %s: %v", exportID, err)
+		cfg.db.FailDataExport(ctx, exportID)
+		return
+	}
+	expiresAt := time.Now().Add(dataExportDuration)
+
+	completeDataExportParams := database.CompleteDataExportParams{
+		ID:		exportID,
+		Archive:	buf.Bytes(),
+		DownloadToken:	sql.NullString{String: downloadToken, Valid: true},
+		ExpiresAt:	sql.NullTime{Time: expiresAt, Valid: true},
+	}
+	if err := cfg.db.CompleteDataExport(ctx, completeDataExportParams); err != nil {
+		log.Printf("Error saving data export %s: %v", exportID, err)
+		cfg.db.FailDataExport(ctx, exportID)
+		return
+	}
+
+	user, err := cfg.db.GetUserByID(ctx, userID)
+	if err != nil {
+		log.Printf("Error finding user %s for data export: %v", userID, err)
+		return
+	}
+	msg := mailer.Message{
+		To:		user.Email,
+		Subject:	"Your Chirpy data export is ready",
+		Body:		fmt.Sprintf("Download your data from the link below:\n\n%s\n\nThe link expires in 7 days.", cfg.dataExportDownloadURL(downloadToken)),
+	}
+	if err := cfg.mailer.Send(ctx, msg); err != nil {
+		log.Printf("Error sending data export email to user %s: %v", userID, err)
+	}
+}
+
+func (cfg *apiConfig) collectDataExport(ctx context.Context, userID uuid.UUID, buf *bytes.Buffer) error {
+	user, err := cfg.db.GetUserByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("Error getting user: %w", err)
+	}
+	totp, err := cfg.db.GetUserTOTP(ctx, userID)
+	twoFactorEnabled := err == nil && totp.ConfirmedAt.Valid
+
+	export := archive.Export{
+		Manifest:	archive.Manifest{
+			ExportedAt:	time.Now().UTC(),
+			UserID:		user.ID,
+		},
+		Profile:	archive.Profile{
+			ID:			user.ID,
+			CreatedAt:		user.CreatedAt,
+			UpdatedAt:		user.UpdatedAt,
+			Email:			user.Email,
+			Handle:			user.Handle,
+			DisplayName:		user.DisplayName,
+			Bio:			user.Bio,
+			Location:		user.Location,
+			Website:		user.Website,
+			IsChirpyRed:		user.IsChirpyRed,
+			IsEmailVerified:	user.EmailVerifiedAt.Valid,
+			TwoFactorEnabled:	twoFactorEnabled,
+		},
+	}
+
+	identities, err := cfg.db.GetUserIdentitiesByUser(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("Error getting linked identities: %w", err)
+	}
+	for _, identity := range identities {
+		export.Profile.Identities = append(export.Profile.Identities, archive.Identity{
+			Provider:	identity.Provider,
+			Email:		identity.Email,
+			LinkedAt:	identity.CreatedAt,
+		})
+	}
+
+	getChirpsByUserParams := database.GetChirpsByUserParams{
+		UserID:		userID,
+		ViewerID:	userID,
+	}
+	chirps, err := cfg.db.GetChirpsByUser(ctx, getChirpsByUserParams)
+	if err != nil {
+		return fmt.Errorf("Error getting chirps: %w", err)
+	}
+	scheduledChirps, err := cfg.db.GetScheduledChirpsByUser(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("Error getting scheduled chirps: %w", err)
+	}
+	for _, chirp := range append(chirps, scheduledChirps...) {
+		exported := archive.Chirp{
+			ID:		chirp.ID,
+			CreatedAt:	chirp.CreatedAt,
+			UpdatedAt:	chirp.UpdatedAt,
+			Body:		chirp.Body,
+			Visibility:	chirp.Visibility,
+			ContentWarning:	chirp.ContentWarning,
+		}
+		if !chirp.PublishedAt.Valid {
+			exported.PublishAt = &chirp.PublishAt.Time
+		}
+		export.Chirps = append(export.Chirps, exported)
+	}
+
+	refreshTokens, err := cfg.db.GetRefreshTokensByUser(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("Error getting sessions: %w", err)
+	}
+	for _, refreshToken := range refreshTokens {
+		session := archive.Session{
+			CreatedAt:	refreshToken.CreatedAt,
+			ExpiresAt:	refreshToken.ExpiresAt,
+			Scope:		refreshToken.Scope,
+		}
+		if refreshToken.RevokedAt.Valid {
+			session.RevokedAt = &refreshToken.RevokedAt.Time
+		}
+		if refreshToken.ClientID.Valid {
+			session.ClientID = &refreshToken.ClientID.UUID
+		}
+		export.Sessions = append(export.Sessions, session)
+	}
+
+	following, err := cfg.db.GetFollowing(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("Error getting follows: %w", err)
+	}
+	for _, follow := range following {
+		export.Following = append(export.Following, archive.Follow{
+			UserID:	follow.ID,
+			Handle:	follow.Handle,
+			Since:	follow.CreatedAt,
+		})
+	}
+
+	followers, err := cfg.db.GetFollowers(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("Error getting followers: %w", err)
+	}
+	for _, follower := range followers {
+		export.Followers = append(export.Followers, archive.Follow{
+			UserID:	follower.ID,
+			Handle:	follower.Handle,
+			Since:	follower.CreatedAt,
+		})
+	}
+
+	return archive.Write(buf, export)
+}
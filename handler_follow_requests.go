@@ -0,0 +1,130 @@
+package main
+
+import (
+	"time"
+	"context"
+	"net/http"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/kmilanbanda/chirpy/internal/auth"
+	"github.com/kmilanbanda/chirpy/internal/database"
+)
+
+type followRequestResponse struct {
+	UserID		uuid.UUID	`json:"user_id"`
+	Handle		string		`json:"handle"`
+	RequestedAt	time.Time	`json:"requested_at"`
+}
+
+// handlerGetFollowRequests lists the users waiting for the logged in user to approve
+// their follow, oldest first
+func (cfg *apiConfig) handlerGetFollowRequests(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	requests, err := cfg.db.GetFollowRequests(context.Background(), userID)
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error getting follow requests")
+		return
+	}
+
+	resp := []followRequestResponse{}
+	for _, request := range requests {
+		resp = append(resp, followRequestResponse{
+			UserID:		request.ID,
+			Handle:		request.Handle,
+			RequestedAt:	request.CreatedAt,
+		})
+	}
+
+	w.WriteHeader(http.StatusOK)
+	dat, _ := json.Marshal(resp)
+	w.Write(dat)
+}
+
+func (cfg *apiConfig) handlerApproveFollowRequest(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	follower, err := cfg.getUserByHandleOrID(context.Background(), req.PathValue("handleOrID"))
+	if err != nil {
+		handleErrorResponse(w, http.StatusNotFound, "Error finding user")
+		return
+	}
+
+	approveFollowRequestParams := database.ApproveFollowRequestParams{
+		FollowerID:	follower.ID,
+		FolloweeID:	userID,
+	}
+	rows, err := cfg.db.ApproveFollowRequest(context.Background(), approveFollowRequestParams)
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error approving follow request")
+		return
+	}
+	if rows == 0 {
+		handleErrorResponse(w, http.StatusNotFound, "Error finding follow request")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerRejectFollowRequest deletes the request. The requester is not told and can
+// ask again later.
+func (cfg *apiConfig) handlerRejectFollowRequest(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	follower, err := cfg.getUserByHandleOrID(context.Background(), req.PathValue("handleOrID"))
+	if err != nil {
+		handleErrorResponse(w, http.StatusNotFound, "Error finding user")
+		return
+	}
+
+	deleteFollowRequestParams := database.DeleteFollowRequestParams{
+		FollowerID:	follower.ID,
+		FolloweeID:	userID,
+	}
+	rows, err := cfg.db.DeleteFollowRequest(context.Background(), deleteFollowRequestParams)
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error rejecting follow request")
+		return
+	}
+	if rows == 0 {
+		handleErrorResponse(w, http.StatusNotFound, "Error finding follow request")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
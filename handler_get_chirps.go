@@ -9,53 +9,98 @@ import (
 	"github.com/kmilanbanda/chirpy/internal/database"
 )
 
+// sortChirps applies the "sort" query parameter of chirp listings. Chirps come from
+// the database oldest first; "desc" reverses that.
+func sortChirps(chirps []database.Chirp, sortOrder string) {
+	if sortOrder == "desc" {
+		sort.Slice(chirps, func(i, j int) bool { return chirps[i].CreatedAt.After(chirps[j].CreatedAt) })
+	}
+}
+
 func (cfg *apiConfig) handlerGetChirps(w http.ResponseWriter, req *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
+	viewerID, err := cfg.optionalViewer(req)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
 	authorIDString := req.URL.Query().Get("author_id")
 	sortOrder := req.URL.Query().Get("sort")
 
 	var chirps []database.Chirp
-	var err error
+	var pinnedChirpID uuid.NullUUID
 	if authorIDString != "" {
-		userID, err := uuid.Parse(authorIDString)
-		if err != nil {
-			handleErrorResponse(w, http.StatusInternalServerError, "Error getting author ID")
+		userID, parseErr := uuid.Parse(authorIDString)
+		if parseErr != nil {
+			handleErrorResponse(w, http.StatusBadRequest, "Error getting author ID")
+			return
+		}
+		if author, err := cfg.db.GetUserByID(context.Background(), userID); err == nil {
+			pinnedChirpID = author.PinnedChirpID
 		}
-		chirps, err = cfg.db.GetChirpsByUser(context.Background(), userID)
+		getChirpsByUserParams := database.GetChirpsByUserParams{
+			UserID:		userID,
+			ViewerID:	viewerID.UUID,
+		}
+		chirps, err = cfg.db.GetChirpsByUser(context.Background(), getChirpsByUserParams)
 	} else {
-		chirps, err = cfg.db.GetChirps(context.Background())
+		chirps, err = cfg.db.GetChirps(context.Background(), viewerID.UUID)
 	}
 	if err != nil {
 		handleErrorResponse(w, http.StatusInternalServerError, "Error getting chirps")
 		return	
 	}
 
-	if sortOrder == "desc" {
-		sort.Slice(chirps, func(i, j int) bool { return chirps[i].CreatedAt.After(chirps[j].CreatedAt) })
+	sortChirps(chirps, sortOrder)
+
+	resp, err := cfg.chirpResponses(context.Background(), viewerID, chirps)
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error getting chirp media")
+		return
 	}
+	resp = pinFirst(resp, pinnedChirpID)
 
 	w.WriteHeader(http.StatusOK)
-	dat, _ := json.Marshal(chirps)
+	dat, _ := json.Marshal(resp)
 	w.Write(dat)
 }
 
 func (cfg *apiConfig) handlerGetChirp(w http.ResponseWriter, req *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
+	viewerID, err := cfg.optionalViewer(req)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
 	chirpID, err := uuid.Parse(req.PathValue("chirpID"))
 	if err != nil {
 		handleErrorResponse(w, http.StatusNotFound, "Error parsing UUID")
 		return
 	}
 
-	chirp, err := cfg.db.GetChirp(context.Background(), uuid.UUID(chirpID))
+	// Chirps the viewer cannot see are reported as missing, not forbidden, so their
+	// existence is not revealed
+	getChirpParams := database.GetChirpParams{
+		ID:		chirpID,
+		ViewerID:	viewerID.UUID,
+	}
+	chirp, err := cfg.db.GetChirp(context.Background(), getChirpParams)
 	if err != nil {
 		handleErrorResponse(w, http.StatusNotFound, "Error getting chirp: id not found")	
 		return	
 	}
 
+	resp, err := cfg.chirpResponse(context.Background(), viewerID, chirp)
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error getting chirp media")
+		return
+	}
+
 	w.WriteHeader(http.StatusOK)
-	dat, _ := json.Marshal(chirp)
+	dat, _ := json.Marshal(resp)
 	w.Write(dat)
 }
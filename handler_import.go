@@ -0,0 +1,277 @@
+package main
+
+import (
+	"io"
+	"log"
+	"fmt"
+	"time"
+	"errors"
+	"context"
+	"strings"
+	"net/http"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/kmilanbanda/chirpy/internal/auth"
+	"github.com/kmilanbanda/chirpy/internal/archive"
+	"github.com/kmilanbanda/chirpy/internal/database"
+)
+
+const (
+	maxImportSize	= 32 << 20
+	// maxImportIssues limits how many skipped or changed chirps are listed in the report.
+	// The counters on the job always cover every chirp.
+	maxImportIssues	= 1000
+)
+
+type importJobResponse struct {
+	ID		uuid.UUID	`json:"id"`
+	CreatedAt	time.Time	`json:"created_at"`
+	Source		string		`json:"source"`
+	Status		string		`json:"status"`
+	Total		int32		`json:"total"`
+	Imported	int32		`json:"imported"`
+	Duplicates	int32		`json:"duplicates"`
+	Censored	int32		`json:"censored"`
+	Skipped		int32		`json:"skipped"`
+	Error		string		`json:"error,omitempty"`
+	CompletedAt	*time.Time	`json:"completed_at,omitempty"`
+	Issues		[]importIssue	`json:"issues,omitempty"`
+}
+
+type importIssue struct {
+	SourceID	string	`json:"source_id"`
+	Reason		string	`json:"reason"`
+}
+
+func newImportJobResponse(job database.ImportJob) importJobResponse {
+	resp := importJobResponse{
+		ID:		job.ID,
+		CreatedAt:	job.CreatedAt,
+		Source:		job.Source,
+		Status:		job.Status,
+		Total:		job.Total,
+		Imported:	job.Imported,
+		Duplicates:	job.Duplicates,
+		Censored:	job.Censored,
+		Skipped:	job.Skipped,
+		Error:		job.Error.String,
+	}
+	if job.CompletedAt.Valid {
+		resp.CompletedAt = &job.CompletedAt.Time
+	}
+	return resp
+}
+
+// handlerCreateImportJob accepts a Chirpy export or a Twitter archive, either as the
+// "archive" field of a multipart form or as the raw request body, and imports its
+// chirps in the background
+func (cfg *apiConfig) handlerCreateImportJob(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	if cfg.requireVerifiedEmail {
+		user, err := cfg.db.GetUserByID(context.Background(), userID)
+		if err != nil {
+			handleErrorResponse(w, http.StatusNotFound, "Error finding user")
+			return
+		}
+		if !user.EmailVerifiedAt.Valid {
+			handleErrorResponse(w, http.StatusForbidden, "Email address must be verified before posting")
+			return
+		}
+	}
+
+	req.Body = http.MaxBytesReader(w, req.Body, maxImportSize)
+	var upload io.Reader = req.Body
+	if strings.HasPrefix(req.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := req.FormFile("archive")
+		if err != nil {
+			handleErrorResponse(w, http.StatusBadRequest, "Error reading the \"archive\" file")
+			return
+		}
+		defer file.Close()
+		upload = file
+	}
+
+	data, err := io.ReadAll(upload)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		handleErrorResponse(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Archive must be smaller than %d MB", maxImportSize>>20))
+		return
+	} else if err != nil {
+		handleErrorResponse(w, http.StatusBadRequest, "Error reading archive")
+		return
+	}
+
+	source, chirps, err := archive.Read(data)
+	if err != nil {
+		handleErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	createImportJobParams := database.CreateImportJobParams{
+		UserID:	userID,
+		Source:	source,
+	}
+	job, err := cfg.db.CreateImportJob(context.Background(), createImportJobParams)
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error creating import job")
+		return
+	}
+
+	go cfg.runImportJob(job.ID, userID, chirps)
+
+	w.WriteHeader(http.StatusAccepted)
+	dat, _ := json.Marshal(newImportJobResponse(job))
+	w.Write(dat)
+}
+
+func (cfg *apiConfig) handlerGetImportJob(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	jobID, err := uuid.Parse(req.PathValue("jobID"))
+	if err != nil {
+		handleErrorResponse(w, http.StatusNotFound, "Error parsing import job ID")
+		return
+	}
+
+	job, err := cfg.db.GetImportJob(context.Background(), jobID)
+	if err != nil || job.UserID != userID {
+		handleErrorResponse(w, http.StatusNotFound, "Error finding import job")
+		return
+	}
+
+	issues, err := cfg.db.GetImportJobIssues(context.Background(), jobID)
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error getting import report")
+		return
+	}
+
+	resp := newImportJobResponse(job)
+	for _, issue := range issues {
+		resp.Issues = append(resp.Issues, importIssue{
+			SourceID:	issue.SourceID,
+			Reason:		issue.Reason,
+		})
+	}
+
+	w.WriteHeader(http.StatusOK)
+	dat, _ := json.Marshal(resp)
+	w.Write(dat)
+}
+
+// runImportJob applies the same length limit and profanity filter as posted chirps.
+// Chirps that already exist with the same text and timestamp are skipped, so importing
+// the same archive twice is harmless.
+func (cfg *apiConfig) runImportJob(jobID, userID uuid.UUID, chirps []archive.ImportedChirp) {
+	ctx := context.Background()
+
+	startImportJobParams := database.StartImportJobParams{
+		ID:	jobID,
+		Total:	int32(len(chirps)),
+	}
+	if err := cfg.db.StartImportJob(ctx, startImportJobParams); err != nil {
+		log.Printf("Error starting import job %s: %v", jobID, err)
+	}
+
+	finishImportJobParams := database.FinishImportJobParams{
+		ID:	jobID,
+		Status:	"done",
+	}
+	issues := 0
+	report := func(sourceID, reason string) {
+		if issues >= maxImportIssues {
+			return
+		}
+		issues++
+		createImportJobIssueParams := database.CreateImportJobIssueParams{
+			JobID:		jobID,
+			SourceID:	sourceID,
+			Reason:		reason,
+		}
+		if err := cfg.db.CreateImportJobIssue(ctx, createImportJobIssueParams); err != nil {
+			log.Printf("Error saving import issue for job %s: %v", jobID, err)
+		}
+	}
+
+	profanities := getProfaneWords()
+	for _, chirp := range chirps {
+		body := strings.TrimSpace(chirp.Body)
+		contentWarning := strings.TrimSpace(chirp.ContentWarning)
+		if chirp.IsRetweet {
+			finishImportJobParams.Skipped++
+			report(chirp.SourceID, "Skipped retweet")
+			continue
+		} else if body == "" {
+			finishImportJobParams.Skipped++
+			report(chirp.SourceID, "Skipped empty chirp")
+			continue
+		} else if len(body) > cfg.maxChirpLength {
+			finishImportJobParams.Skipped++
+			report(chirp.SourceID, fmt.Sprintf("Skipped chirp longer than %d characters", cfg.maxChirpLength))
+			continue
+		} else if err := validateProfileField("Content warning", contentWarning, maxContentWarningLength); err != nil {
+			finishImportJobParams.Skipped++
+			report(chirp.SourceID, "Skipped chirp: "+err.Error())
+			continue
+		}
+
+		censored := censorProfanity(body, profanities)
+		importChirpParams := database.ImportChirpParams{
+			CreatedAt:	chirp.CreatedAt,
+			Body:		censored,
+			UserID:		userID,
+			Visibility:	chirp.Visibility,
+			BodyWords:	normalizeWords(censored),
+			ContentWarning:	contentWarning,
+			ContentWarningWords:	normalizeWords(contentWarning),
+		}
+		imported, err := cfg.db.ImportChirp(ctx, importChirpParams)
+		if err == sql.ErrNoRows {
+			finishImportJobParams.Duplicates++
+			continue
+		} else if err == nil {
+			// Mentioned chirps are only visible to the users they mention
+			err = saveMentions(ctx, cfg.db, imported)
+		}
+		if err != nil {
+			log.Printf("Error importing chirp %s for job %s: %v", chirp.SourceID, jobID, err)
+			finishImportJobParams.Status = "failed"
+			finishImportJobParams.Error = sql.NullString{String: "Error saving chirps, the import stopped early", Valid: true}
+			break
+		}
+
+		finishImportJobParams.Imported++
+		if censored != body {
+			finishImportJobParams.Censored++
+			report(chirp.SourceID, "Imported with profanity censored")
+		}
+	}
+
+	if err := cfg.db.FinishImportJob(ctx, finishImportJobParams); err != nil {
+		log.Printf("Error finishing import job %s: %v", jobID, err)
+	}
+}
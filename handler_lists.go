@@ -0,0 +1,456 @@
+package main
+
+import (
+	"fmt"
+	"time"
+	"context"
+	"strings"
+	"net/http"
+	"database/sql"
+	"unicode/utf8"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/kmilanbanda/chirpy/internal/auth"
+	"github.com/kmilanbanda/chirpy/internal/database"
+)
+
+const (
+	maxListNameLength	= 25
+	maxListsPerUser		= 100
+	maxListMembers		= 500
+)
+
+type listResponse struct {
+	ID		uuid.UUID	`json:"id"`
+	CreatedAt	time.Time	`json:"created_at"`
+	UpdatedAt	time.Time	`json:"updated_at"`
+	UserID		uuid.UUID	`json:"user_id"`
+	Name		string		`json:"name"`
+	Private		bool		`json:"private"`
+}
+
+func newListResponse(list database.List) listResponse {
+	return listResponse{
+		ID:		list.ID,
+		CreatedAt:	list.CreatedAt,
+		UpdatedAt:	list.UpdatedAt,
+		UserID:		list.UserID,
+		Name:		list.Name,
+		Private:	list.Private,
+	}
+}
+
+type listMemberResponse struct {
+	UserID	uuid.UUID	`json:"user_id"`
+	Handle	string		`json:"handle"`
+	AddedAt	time.Time	`json:"added_at"`
+}
+
+func validateListName(name string) error {
+	if name == "" || utf8.RuneCountInString(name) > maxListNameLength {
+		return fmt.Errorf("List name must be 1 to %d characters", maxListNameLength)
+	}
+	return nil
+}
+
+// getVisibleList returns the list if viewerID may read it. Private lists are only
+// visible to their owner and are reported as not found to everyone else.
+func (cfg *apiConfig) getVisibleList(ctx context.Context, viewerID uuid.NullUUID, listIDString string) (database.List, error) {
+	listID, err := uuid.Parse(listIDString)
+	if err != nil {
+		return database.List{}, err
+	}
+	list, err := cfg.db.GetList(ctx, listID)
+	if err != nil {
+		return database.List{}, err
+	}
+	if list.Private && (!viewerID.Valid || list.UserID != viewerID.UUID) {
+		return database.List{}, sql.ErrNoRows
+	}
+	return list, nil
+}
+
+// getOwnList returns the list if it belongs to userID. Other users' lists are
+// reported as not found.
+func (cfg *apiConfig) getOwnList(ctx context.Context, userID uuid.UUID, listIDString string) (database.List, error) {
+	list, err := cfg.getVisibleList(ctx, uuid.NullUUID{UUID: userID, Valid: true}, listIDString)
+	if err != nil {
+		return database.List{}, err
+	}
+	if list.UserID != userID {
+		return database.List{}, sql.ErrNoRows
+	}
+	return list, nil
+}
+
+func (cfg *apiConfig) handlerCreateList(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	type request struct {
+		Name	string	`json:"name"`
+		Private	bool	`json:"private"`
+	}
+
+	var reqBody request
+	if err := json.NewDecoder(req.Body).Decode(&reqBody); err != nil {
+		handleErrorResponse(w, http.StatusBadRequest, "Error decoding request parameters")
+		return
+	}
+	name := strings.TrimSpace(reqBody.Name)
+	if err := validateListName(name); err != nil {
+		handleErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	count, err := cfg.db.CountListsByUser(context.Background(), userID)
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error counting lists")
+		return
+	}
+	if count >= maxListsPerUser {
+		handleErrorResponse(w, http.StatusConflict, fmt.Sprintf("You can have at most %d lists", maxListsPerUser))
+		return
+	}
+
+	createListParams := database.CreateListParams{
+		UserID:		userID,
+		Name:		name,
+		Private:	reqBody.Private,
+	}
+	list, err := cfg.db.CreateList(context.Background(), createListParams)
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error creating list")
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	dat, _ := json.Marshal(newListResponse(list))
+	w.Write(dat)
+}
+
+// handlerGetLists lists the logged in user's own lists, public and private
+func (cfg *apiConfig) handlerGetLists(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	lists, err := cfg.db.GetListsByUser(context.Background(), userID)
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error getting lists")
+		return
+	}
+
+	resp := []listResponse{}
+	for _, list := range lists {
+		resp = append(resp, newListResponse(list))
+	}
+
+	w.WriteHeader(http.StatusOK)
+	dat, _ := json.Marshal(resp)
+	w.Write(dat)
+}
+
+func (cfg *apiConfig) handlerGetList(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	viewerID, err := cfg.optionalViewer(req)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	list, err := cfg.getVisibleList(context.Background(), viewerID, req.PathValue("listID"))
+	if err != nil {
+		handleErrorResponse(w, http.StatusNotFound, "Error finding list")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	dat, _ := json.Marshal(newListResponse(list))
+	w.Write(dat)
+}
+
+// handlerUpdateList only changes the fields present in the request body
+func (cfg *apiConfig) handlerUpdateList(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	list, err := cfg.getOwnList(context.Background(), userID, req.PathValue("listID"))
+	if err != nil {
+		handleErrorResponse(w, http.StatusNotFound, "Error finding list")
+		return
+	}
+
+	type request struct {
+		Name	*string	`json:"name"`
+		Private	*bool	`json:"private"`
+	}
+
+	var reqBody request
+	if err := json.NewDecoder(req.Body).Decode(&reqBody); err != nil {
+		handleErrorResponse(w, http.StatusBadRequest, "Error decoding request parameters")
+		return
+	}
+
+	updateListParams := database.UpdateListParams{
+		ID:		list.ID,
+		Name:		list.Name,
+		Private:	list.Private,
+	}
+	if reqBody.Name != nil {
+		updateListParams.Name = strings.TrimSpace(*reqBody.Name)
+		if err := validateListName(updateListParams.Name); err != nil {
+			handleErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	if reqBody.Private != nil {
+		updateListParams.Private = *reqBody.Private
+	}
+
+	list, err = cfg.db.UpdateList(context.Background(), updateListParams)
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error updating list")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	dat, _ := json.Marshal(newListResponse(list))
+	w.Write(dat)
+}
+
+func (cfg *apiConfig) handlerDeleteList(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	list, err := cfg.getOwnList(context.Background(), userID, req.PathValue("listID"))
+	if err != nil {
+		handleErrorResponse(w, http.StatusNotFound, "Error finding list")
+		return
+	}
+
+	if err := cfg.db.DeleteList(context.Background(), list.ID); err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error deleting list")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerGetListMembers(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	viewerID, err := cfg.optionalViewer(req)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	list, err := cfg.getVisibleList(context.Background(), viewerID, req.PathValue("listID"))
+	if err != nil {
+		handleErrorResponse(w, http.StatusNotFound, "Error finding list")
+		return
+	}
+
+	members, err := cfg.db.GetListMembers(context.Background(), list.ID)
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error getting list members")
+		return
+	}
+
+	resp := []listMemberResponse{}
+	for _, member := range members {
+		resp = append(resp, listMemberResponse{
+			UserID:		member.ID,
+			Handle:		member.Handle,
+			AddedAt:	member.CreatedAt,
+		})
+	}
+
+	w.WriteHeader(http.StatusOK)
+	dat, _ := json.Marshal(resp)
+	w.Write(dat)
+}
+
+// handlerAddListMember adds a user to one of the caller's lists. Users who have
+// blocked the caller, or whom the caller has blocked, cannot be added.
+func (cfg *apiConfig) handlerAddListMember(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	list, err := cfg.getOwnList(context.Background(), userID, req.PathValue("listID"))
+	if err != nil {
+		handleErrorResponse(w, http.StatusNotFound, "Error finding list")
+		return
+	}
+
+	member, err := cfg.getUserByHandleOrID(context.Background(), req.PathValue("handleOrID"))
+	if err != nil {
+		handleErrorResponse(w, http.StatusNotFound, "Error finding user")
+		return
+	}
+
+	isBlockedBetweenParams := database.IsBlockedBetweenParams{
+		UserID:		userID,
+		OtherID:	member.ID,
+	}
+	blocked, err := cfg.db.IsBlockedBetween(context.Background(), isBlockedBetweenParams)
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error adding list member")
+		return
+	}
+	if blocked {
+		handleErrorResponse(w, http.StatusForbidden, "You cannot add this user to a list")
+		return
+	}
+
+	count, err := cfg.db.CountListMembers(context.Background(), list.ID)
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error counting list members")
+		return
+	}
+	if count >= maxListMembers {
+		handleErrorResponse(w, http.StatusConflict, fmt.Sprintf("A list can have at most %d members", maxListMembers))
+		return
+	}
+
+	addListMemberParams := database.AddListMemberParams{
+		ListID:	list.ID,
+		UserID:	member.ID,
+	}
+	if err := cfg.db.AddListMember(context.Background(), addListMemberParams); err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error adding list member")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerRemoveListMember(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	list, err := cfg.getOwnList(context.Background(), userID, req.PathValue("listID"))
+	if err != nil {
+		handleErrorResponse(w, http.StatusNotFound, "Error finding list")
+		return
+	}
+
+	member, err := cfg.getUserByHandleOrID(context.Background(), req.PathValue("handleOrID"))
+	if err != nil {
+		handleErrorResponse(w, http.StatusNotFound, "Error finding user")
+		return
+	}
+
+	removeListMemberParams := database.RemoveListMemberParams{
+		ListID:	list.ID,
+		UserID:	member.ID,
+	}
+	if err := cfg.db.RemoveListMember(context.Background(), removeListMemberParams); err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error removing list member")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerGetListChirps lists chirps by the list's members. It works like GET /api/chirps:
+// the chirps are filtered for the reader exactly the same way, whoever owns the list,
+// and come oldest first unless "sort" is "desc".
+func (cfg *apiConfig) handlerGetListChirps(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	viewerID, err := cfg.optionalViewer(req)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	list, err := cfg.getVisibleList(context.Background(), viewerID, req.PathValue("listID"))
+	if err != nil {
+		handleErrorResponse(w, http.StatusNotFound, "Error finding list")
+		return
+	}
+
+	getListChirpsParams := database.GetListChirpsParams{
+		ListID:		list.ID,
+		ViewerID:	viewerID.UUID,
+	}
+	chirps, err := cfg.db.GetListChirps(context.Background(), getListChirpsParams)
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error getting chirps")
+		return
+	}
+	sortChirps(chirps, req.URL.Query().Get("sort"))
+
+	resp, err := cfg.chirpResponses(context.Background(), viewerID, chirps)
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error getting chirp media")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	dat, _ := json.Marshal(resp)
+	w.Write(dat)
+}
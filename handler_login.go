@@ -1,6 +1,8 @@
 package main
 
 import (
+	"log"
+	"strconv"
 	"net/http"
 	"encoding/json"
 	"time"
@@ -11,6 +13,8 @@ import (
 	"github.com/kmilanbanda/chirpy/internal/database"
 )
 
+const mfaTokenDuration = time.Minute * 5
+
 func (cfg *apiConfig) handlerLogin(w http.ResponseWriter, req *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -25,18 +29,80 @@ func (cfg *apiConfig) handlerLogin(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	accountKey := accountLoginKey(reqBody.Email)
+	ipKey := ipLoginKey(req)
+	if lockedUntil := cfg.loginLockedUntil(context.Background(), accountKey, ipKey); !lockedUntil.IsZero() {
+		retryAfter := int(time.Until(lockedUntil).Seconds()) + 1
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		handleErrorResponse(w, http.StatusTooManyRequests, "Too many failed login attempts, try again later")
+		return
+	}
+
+	// Unknown emails still pay for a hash comparison so both failures take about as long
 	user, err := cfg.db.GetUserByEmail(context.Background(), reqBody.Email)
 	if err != nil {
-		handleErrorResponse(w, http.StatusNotFound, "Error finding user")
+		auth.CheckPasswordHash(cfg.dummyPasswordHash, reqBody.Password)
+	} else {
+		err = auth.CheckPasswordHash(user.HashedPassword, reqBody.Password)
+	}
+	if err != nil {
+		if err := cfg.recordLoginFailure(context.Background(), accountKey, accountLoginThreshold); err != nil {
+			log.Printf("Error recording failed login: %v", err)
+		}
+		if err := cfg.recordLoginFailure(context.Background(), ipKey, ipLoginThreshold); err != nil {
+			log.Printf("Error recording failed login: %v", err)
+		}
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid credentials")
 		return
 	}
 
-	err = auth.CheckPasswordHash(user.HashedPassword, reqBody.Password)
-	if err != nil {
-		handleErrorResponse(w, http.StatusUnauthorized, "Incorrect Password")
+	if err := cfg.db.ClearLoginFailures(context.Background(), accountKey); err != nil {
+		log.Printf("Error clearing failed logins: %v", err)
+	}
+
+	if cfg.hasher.NeedsRehash(user.HashedPassword) {
+		cfg.rehashPassword(user, reqBody.Password)
+	}
+
+	cfg.completeLogin(w, user)
+}
+
+// completeLogin asks for a second factor when the user has one, otherwise it issues tokens
+func (cfg *apiConfig) completeLogin(w http.ResponseWriter, user database.User) {
+	totp, err := cfg.db.GetUserTOTP(context.Background(), user.ID)
+	if err == nil && totp.ConfirmedAt.Valid {
+		mfaToken, err := auth.MakeMFAToken(user.ID, cfg.secret, mfaTokenDuration)
+		if err != nil {
+			handleErrorResponse(w, http.StatusInternalServerError, "Error making MFA token")
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		resp := struct{
+			MFARequired	bool	`json:"mfa_required"`
+			MFAToken	string	`json:"mfa_token"`
+		}{
+			MFARequired:	true,
+			MFAToken:	mfaToken,
+		}
+		dat, _ := json.Marshal(resp)
+		w.Write(dat)
 		return
 	}
 
+	cfg.writeLoginResponse(w, user)
+}
+
+// writeLoginResponse issues a new access and refresh token pair for a fully authenticated user
+func (cfg *apiConfig) writeLoginResponse(w http.ResponseWriter, user database.User) {
+	if user.DeletionScheduledFor.Valid {
+		if err := cfg.db.CancelUserDeletion(context.Background(), user.ID); err != nil {
+			handleErrorResponse(w, http.StatusInternalServerError, "Error cancelling account deletion")
+			return
+		}
+		log.Printf("Cancelled scheduled deletion of user %s after login", user.ID)
+	}
+
 	accessTokenDuration := time.Hour * 1
 	refreshTokenDuration := time.Hour * 24 * 60
 
@@ -66,6 +132,8 @@ func (cfg *apiConfig) handlerLogin(w http.ResponseWriter, req *http.Request) {
 		Token		string		`json:"token"`
 		RefreshToken	string		`json:"refresh_token"`
 		IsChirpyRed	bool		`json:"is_chirpy_red"`
+		IsEmailVerified	bool		`json:"is_email_verified"`
+		Handle		string		`json:"handle"`
 	}{
 		ID:		user.ID,
 		CreatedAt:	user.CreatedAt,
@@ -74,7 +142,27 @@ func (cfg *apiConfig) handlerLogin(w http.ResponseWriter, req *http.Request) {
 		Token:		token,
 		RefreshToken:	refreshToken,
 		IsChirpyRed:	user.IsChirpyRed,
+		IsEmailVerified:	user.EmailVerifiedAt.Valid,
+		Handle:		user.Handle,
 	}
 	dat, _  := json.Marshal(resp)
 	w.Write(dat)	
 }
+
+// rehashPassword upgrades a user's stored hash to the preferred algorithm and settings.
+// It is called after a successful login, the only time the plain password is known.
+func (cfg *apiConfig) rehashPassword(user database.User, password string) {
+	hashedPassword, err := cfg.hasher.Hash(password)
+	if err != nil {
+		log.Printf("Error rehashing password for user %s: %v", user.ID, err)
+		return
+	}
+
+	updateUserPasswordParams := database.UpdateUserPasswordParams{
+		ID:		user.ID,
+		HashedPassword:	hashedPassword,
+	}
+	if err := cfg.db.UpdateUserPassword(context.Background(), updateUserPasswordParams); err != nil {
+		log.Printf("Error rehashing password for user %s: %v", user.ID, err)
+	}
+}
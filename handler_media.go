@@ -0,0 +1,262 @@
+package main
+
+import (
+	"io"
+	"fmt"
+	"log"
+	"time"
+	"bytes"
+	"errors"
+	"context"
+	"strings"
+	"net/http"
+	"database/sql"
+	"unicode/utf8"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/kmilanbanda/chirpy/internal/auth"
+	"github.com/kmilanbanda/chirpy/internal/imaging"
+	"github.com/kmilanbanda/chirpy/internal/database"
+)
+
+const (
+	maxChirpMedia		= 4
+	maxAltTextLength	= 1000
+	maxMediaDimension	= 2048
+	mediaThumbnailSize	= 400
+	// unattachedMediaTTL is how long an upload can wait to be attached to a chirp
+	unattachedMediaTTL	= time.Hour * 24
+	mediaPurgeInterval	= time.Hour
+)
+
+// handlerUploadMedia is the first half of attaching images to a chirp. The upload is
+// resized, stripped of metadata and stored; its ID is then passed as one of the
+// media_ids when posting the chirp.
+func (cfg *apiConfig) handlerUploadMedia(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateScopedJWT(token, cfg.secret, "chirps:write")
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	req.Body = http.MaxBytesReader(w, req.Body, maxImageUploadSize)
+	file, _, err := req.FormFile("image")
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		handleErrorResponse(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Image must be smaller than %d MB", maxImageUploadSize>>20))
+		return
+	} else if err != nil {
+		handleErrorResponse(w, http.StatusBadRequest, "Error reading the \"image\" file")
+		return
+	}
+	defer file.Close()
+
+	altText := strings.TrimSpace(req.FormValue("alt_text"))
+	if utf8.RuneCountInString(altText) > maxAltTextLength {
+		handleErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("Alt text must be at most %d characters", maxAltTextLength))
+		return
+	}
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		handleErrorResponse(w, http.StatusBadRequest, "Error reading image")
+		return
+	}
+
+	img, err := imaging.Decode(data)
+	if errors.Is(err, imaging.ErrUnsupportedFormat) || errors.Is(err, imaging.ErrTooLarge) {
+		handleErrorResponse(w, http.StatusUnsupportedMediaType, err.Error())
+		return
+	} else if err != nil {
+		handleErrorResponse(w, http.StatusBadRequest, "Error decoding image")
+		return
+	}
+
+	full := imaging.Fit(img, maxMediaDimension, maxMediaDimension)
+	thumbnail := imaging.Fit(full, mediaThumbnailSize, mediaThumbnailSize)
+
+	var fullBuf, thumbnailBuf bytes.Buffer
+	contentType, err := imaging.Encode(&fullBuf, full)
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error encoding image")
+		return
+	}
+	thumbnailContentType, err := imaging.Encode(&thumbnailBuf, thumbnail)
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error encoding thumbnail")
+		return
+	}
+
+	name := fmt.Sprintf("media/%s/%s", userID, uuid.NewString())
+	key := name + imaging.Extension(contentType)
+	thumbnailKey := name + "_thumb" + imaging.Extension(thumbnailContentType)
+	if err := cfg.blobStore.Put(context.Background(), key, fullBuf.Bytes(), contentType); err != nil {
+		log.Printf("Error storing media for user %s: %v", userID, err)
+		handleErrorResponse(w, http.StatusInternalServerError, "Error storing image")
+		return
+	}
+	if err := cfg.blobStore.Put(context.Background(), thumbnailKey, thumbnailBuf.Bytes(), thumbnailContentType); err != nil {
+		log.Printf("Error storing thumbnail for user %s: %v", userID, err)
+		cfg.deleteBlob(sql.NullString{String: key, Valid: true})
+		handleErrorResponse(w, http.StatusInternalServerError, "Error storing image")
+		return
+	}
+
+	createChirpMediaParams := database.CreateChirpMediaParams{
+		UserID:		userID,
+		ContentType:	contentType,
+		BlobKey:	key,
+		ThumbnailKey:	thumbnailKey,
+		Width:		int32(full.Rect.Dx()),
+		Height:		int32(full.Rect.Dy()),
+		AltText:	altText,
+		Blurhash:	imaging.Blurhash(thumbnail, 4, 3),
+	}
+	media, err := cfg.db.CreateChirpMedia(context.Background(), createChirpMediaParams)
+	if err != nil {
+		cfg.deleteMediaBlobs([]database.ChirpMedium{{BlobKey: key, ThumbnailKey: thumbnailKey}})
+		handleErrorResponse(w, http.StatusInternalServerError, "Error saving media")
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	dat, _ := json.Marshal(cfg.newChirpMediaResponse(media))
+	w.Write(dat)
+}
+
+// handlerUpdateMedia changes the alt text of an upload, attached or not
+func (cfg *apiConfig) handlerUpdateMedia(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateScopedJWT(token, cfg.secret, "chirps:write")
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	mediaID, err := uuid.Parse(req.PathValue("mediaID"))
+	if err != nil {
+		handleErrorResponse(w, http.StatusNotFound, "Error finding media")
+		return
+	}
+
+	type request struct {
+		AltText	string	`json:"alt_text"`
+	}
+
+	var reqBody request
+	if err := json.NewDecoder(req.Body).Decode(&reqBody); err != nil {
+		handleErrorResponse(w, http.StatusBadRequest, "Error decoding request parameters")
+		return
+	}
+	altText := strings.TrimSpace(reqBody.AltText)
+	if utf8.RuneCountInString(altText) > maxAltTextLength {
+		handleErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("Alt text must be at most %d characters", maxAltTextLength))
+		return
+	}
+
+	media, err := cfg.db.GetChirpMedia(context.Background(), mediaID)
+	if err != nil || media.UserID != userID {
+		handleErrorResponse(w, http.StatusNotFound, "Error finding media")
+		return
+	}
+
+	updateChirpMediaAltTextParams := database.UpdateChirpMediaAltTextParams{
+		ID:		mediaID,
+		AltText:	altText,
+	}
+	media, err = cfg.db.UpdateChirpMediaAltText(context.Background(), updateChirpMediaAltTextParams)
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error updating media")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	dat, _ := json.Marshal(cfg.newChirpMediaResponse(media))
+	w.Write(dat)
+}
+
+// checkChirpMedia makes sure every ID is an upload of the user that is not attached
+// to a chirp yet
+func (cfg *apiConfig) checkChirpMedia(ctx context.Context, userID uuid.UUID, mediaIDs []uuid.UUID) error {
+	if len(mediaIDs) > maxChirpMedia {
+		return fmt.Errorf("A chirp can have at most %d images", maxChirpMedia)
+	}
+	seen := map[uuid.UUID]struct{}{}
+	for _, mediaID := range mediaIDs {
+		if _, duplicate := seen[mediaID]; duplicate {
+			return fmt.Errorf("Media %s is listed more than once", mediaID)
+		}
+		seen[mediaID] = struct{}{}
+
+		media, err := cfg.db.GetChirpMedia(ctx, mediaID)
+		if err != nil || media.UserID != userID {
+			return fmt.Errorf("Media %s was not found", mediaID)
+		}
+		if media.ChirpID.Valid {
+			return fmt.Errorf("Media %s is already attached to a chirp", mediaID)
+		}
+	}
+	return nil
+}
+
+// attachChirpMedia attaches the uploads to the chirp in the order they were given
+func attachChirpMedia(ctx context.Context, q *database.Queries, userID, chirpID uuid.UUID, mediaIDs []uuid.UUID) error {
+	for i, mediaID := range mediaIDs {
+		attachChirpMediaParams := database.AttachChirpMediaParams{
+			ID:		mediaID,
+			ChirpID:	uuid.NullUUID{UUID: chirpID, Valid: true},
+			Position:	int32(i),
+			UserID:		userID,
+		}
+		rows, err := q.AttachChirpMedia(ctx, attachChirpMediaParams)
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return fmt.Errorf("Media %s is already attached to a chirp", mediaID)
+		}
+	}
+	return nil
+}
+
+func (cfg *apiConfig) deleteMediaBlobs(media []database.ChirpMedium) {
+	for _, m := range media {
+		cfg.deleteBlob(sql.NullString{String: m.BlobKey, Valid: true})
+		cfg.deleteBlob(sql.NullString{String: m.ThumbnailKey, Valid: true})
+	}
+}
+
+// purgeUnattachedMedia deletes uploads that were never attached to a chirp until ctx
+// is done
+func (cfg *apiConfig) purgeUnattachedMedia(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		media, err := cfg.db.DeleteUnattachedChirpMedia(ctx, time.Now().Add(-unattachedMediaTTL))
+		if err != nil {
+			log.Printf("Error deleting unattached media: %v", err)
+		}
+		cfg.deleteMediaBlobs(media)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"time"
+	"context"
+	"net/http"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/kmilanbanda/chirpy/internal/auth"
+	"github.com/kmilanbanda/chirpy/internal/database"
+)
+
+const (
+	maxMutedKeywordsPerUser	= 100
+	maxMutedPhraseLength	= 100
+)
+
+type mutedKeywordResponse struct {
+	ID		uuid.UUID	`json:"id"`
+	Phrase		string		`json:"phrase"`
+	CreatedAt	time.Time	`json:"created_at"`
+	ExpiresAt	*time.Time	`json:"expires_at"`
+}
+
+func newMutedKeywordResponse(keyword database.MutedKeyword) mutedKeywordResponse {
+	resp := mutedKeywordResponse{
+		ID:		keyword.ID,
+		Phrase:		keyword.Phrase,
+		CreatedAt:	keyword.CreatedAt,
+	}
+	if keyword.ExpiresAt.Valid {
+		resp.ExpiresAt = &keyword.ExpiresAt.Time
+	}
+	return resp
+}
+
+// handlerCreateMutedKeyword hides chirps containing the phrase from the user's chirp
+// listings until "expires_at", or for good if it is left out. Muting a phrase that is
+// already muted only changes when it expires.
+func (cfg *apiConfig) handlerCreateMutedKeyword(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	type request struct {
+		Phrase		string		`json:"phrase"`
+		ExpiresAt	*time.Time	`json:"expires_at"`
+	}
+
+	var reqBody request
+	if err := json.NewDecoder(req.Body).Decode(&reqBody); err != nil {
+		handleErrorResponse(w, http.StatusBadRequest, "Error decoding request parameters")
+		return
+	}
+
+	phrase := normalizeWords(reqBody.Phrase)
+	if phrase == "" {
+		handleErrorResponse(w, http.StatusBadRequest, "Phrase is required")
+		return
+	}
+	if err := validateProfileField("Phrase", phrase, maxMutedPhraseLength); err != nil {
+		handleErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var expiresAt sql.NullTime
+	if reqBody.ExpiresAt != nil {
+		if !reqBody.ExpiresAt.After(time.Now()) {
+			handleErrorResponse(w, http.StatusBadRequest, "Expiry must be in the future")
+			return
+		}
+		expiresAt = sql.NullTime{Time: reqBody.ExpiresAt.UTC(), Valid: true}
+	}
+
+	if err := cfg.db.DeleteExpiredMutedKeywords(context.Background(), userID); err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error removing expired muted words")
+		return
+	}
+	count, err := cfg.db.CountMutedKeywordsByUser(context.Background(), userID)
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error counting muted words")
+		return
+	}
+	if count >= maxMutedKeywordsPerUser {
+		handleErrorResponse(w, http.StatusConflict, fmt.Sprintf("You can mute at most %d words or phrases", maxMutedKeywordsPerUser))
+		return
+	}
+
+	createMutedKeywordParams := database.CreateMutedKeywordParams{
+		UserID:		userID,
+		Phrase:		phrase,
+		ExpiresAt:	expiresAt,
+	}
+	keyword, err := cfg.db.CreateMutedKeyword(context.Background(), createMutedKeywordParams)
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error muting phrase")
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	dat, _ := json.Marshal(newMutedKeywordResponse(keyword))
+	w.Write(dat)
+}
+
+// handlerGetMutedKeywords lists the user's muted phrases that have not expired
+func (cfg *apiConfig) handlerGetMutedKeywords(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	keywords, err := cfg.db.GetMutedKeywordsByUser(context.Background(), userID)
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error getting muted words")
+		return
+	}
+
+	resp := []mutedKeywordResponse{}
+	for _, keyword := range keywords {
+		resp = append(resp, newMutedKeywordResponse(keyword))
+	}
+
+	w.WriteHeader(http.StatusOK)
+	dat, _ := json.Marshal(resp)
+	w.Write(dat)
+}
+
+func (cfg *apiConfig) handlerDeleteMutedKeyword(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	keywordID, err := uuid.Parse(req.PathValue("keywordID"))
+	if err != nil {
+		handleErrorResponse(w, http.StatusNotFound, "Error finding muted word")
+		return
+	}
+
+	deleteMutedKeywordParams := database.DeleteMutedKeywordParams{
+		ID:	keywordID,
+		UserID:	userID,
+	}
+	rows, err := cfg.db.DeleteMutedKeyword(context.Background(), deleteMutedKeywordParams)
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error unmuting phrase")
+		return
+	}
+	if rows == 0 {
+		handleErrorResponse(w, http.StatusNotFound, "Error finding muted word")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
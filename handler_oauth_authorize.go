@@ -0,0 +1,282 @@
+package main
+
+import (
+	"log"
+	"time"
+	"errors"
+	"slices"
+	"context"
+	"strings"
+	"net/url"
+	"net/http"
+	"html/template"
+
+	"github.com/google/uuid"
+	"github.com/kmilanbanda/chirpy/internal/auth"
+	"github.com/kmilanbanda/chirpy/internal/database"
+)
+
+const oauthAuthorizationCodeDuration = time.Minute * 2
+
+// oauthScopes are the scopes third-party clients can ask for, with the text shown on
+// the consent page
+var oauthScopes = map[string]string{
+	"chirps:read":	"Read chirps that are visible to you",
+	"chirps:write":	"Post and delete chirps as you",
+}
+
+var consentPage = template.Must(template.New("consent").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Authorize {{.ClientName}}</title></head>
+<body>
+	<h1>{{.ClientName}} wants to access your Chirpy account</h1>
+	<p>It will be able to:</p>
+	<ul>{{range .Scopes}}<li>{{.}}</li>{{end}}</ul>
+	{{if .Error}}<p><strong>{{.Error}}</strong></p>{{end}}
+	<form method="POST" action="/oauth/authorize">
+		{{range $name, $value := .Params}}<input type="hidden" name="{{$name}}" value="{{$value}}">
+		{{end}}
+		<label>Email <input type="email" name="email" required></label>
+		<label>Password <input type="password" name="password" required></label>
+		<label>Two-factor code (if enabled) <input type="text" name="totp_code" autocomplete="one-time-code"></label>
+		<button type="submit" name="decision" value="allow">Allow</button>
+		<button type="submit" name="decision" value="deny" formnovalidate>Deny</button>
+	</form>
+</body>
+</html>
+`))
+
+// authorizeRequest is a validated authorization request from a client
+type authorizeRequest struct {
+	Client		database.OauthClient
+	RedirectURI	string
+	Scope		string
+	State		string
+	CodeChallenge	string
+}
+
+// parseAuthorizeRequest validates the client and redirect URI first. Errors about them
+// are returned as err and must not redirect; anything after that is returned as an
+// OAuth error code that is sent back to the client's redirect URI.
+func (cfg *apiConfig) parseAuthorizeRequest(ctx context.Context, params url.Values) (authorizeRequest, string, error) {
+	clientID, err := uuid.Parse(params.Get("client_id"))
+	if err != nil {
+		return authorizeRequest{}, "", errors.New("Unknown client")
+	}
+	client, err := cfg.db.GetOAuthClient(ctx, clientID)
+	if err != nil {
+		return authorizeRequest{}, "", errors.New("Unknown client")
+	}
+
+	redirectURI := params.Get("redirect_uri")
+	if !slices.Contains(client.RedirectUris, redirectURI) {
+		return authorizeRequest{}, "", errors.New("Redirect URI is not registered for this client")
+	}
+
+	authReq := authorizeRequest{
+		Client:		client,
+		RedirectURI:	redirectURI,
+		State:		params.Get("state"),
+	}
+
+	if params.Get("response_type") != "code" {
+		return authReq, "unsupported_response_type", nil
+	}
+
+	scopes := strings.Fields(params.Get("scope"))
+	if len(scopes) == 0 {
+		scopes = []string{"chirps:read"}
+	}
+	for _, scope := range scopes {
+		if _, ok := oauthScopes[scope]; !ok {
+			return authReq, "invalid_scope", nil
+		}
+	}
+	slices.Sort(scopes)
+	authReq.Scope = strings.Join(slices.Compact(scopes), " ")
+
+	// Public clients cannot keep a secret, so PKCE is what ties the code to them
+	authReq.CodeChallenge = params.Get("code_challenge")
+	method := params.Get("code_challenge_method")
+	if authReq.CodeChallenge == "" {
+		if !client.ClientSecretHash.Valid {
+			return authReq, "invalid_request", nil
+		}
+	} else if method != "S256" {
+		return authReq, "invalid_request", nil
+	}
+
+	return authReq, "", nil
+}
+
+func redirectToClient(w http.ResponseWriter, req *http.Request, authReq authorizeRequest, params url.Values) {
+	if authReq.State != "" {
+		params.Set("state", authReq.State)
+	}
+	target, _ := url.Parse(authReq.RedirectURI)
+	query := target.Query()
+	for name, values := range params {
+		query[name] = values
+	}
+	target.RawQuery = query.Encode()
+	http.Redirect(w, req, target.String(), http.StatusFound)
+}
+
+func renderConsentPage(w http.ResponseWriter, status int, authReq authorizeRequest, params url.Values, errorMessage string) {
+	pageParams := map[string]string{}
+	for _, name := range []string{"client_id", "redirect_uri", "response_type", "scope", "state", "code_challenge", "code_challenge_method"} {
+		pageParams[name] = params.Get(name)
+	}
+	scopes := []string{}
+	for _, scope := range strings.Fields(authReq.Scope) {
+		scopes = append(scopes, oauthScopes[scope])
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	err := consentPage.Execute(w, struct{
+		ClientName	string
+		Scopes		[]string
+		Params		map[string]string
+		Error		string
+	}{
+		ClientName:	authReq.Client.Name,
+		Scopes:		scopes,
+		Params:		pageParams,
+		Error:		errorMessage,
+	})
+	if err != nil {
+		log.Printf("Error rendering consent page: %v", err)
+	}
+}
+
+// handlerOAuthAuthorize shows the consent page for an authorization code request
+func (cfg *apiConfig) handlerOAuthAuthorize(w http.ResponseWriter, req *http.Request) {
+	params := req.URL.Query()
+	authReq, oauthErr, err := cfg.parseAuthorizeRequest(context.Background(), params)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		handleErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	} else if oauthErr != "" {
+		redirectToClient(w, req, authReq, url.Values{"error": {oauthErr}})
+		return
+	}
+
+	renderConsentPage(w, http.StatusOK, authReq, params, "")
+}
+
+// handlerOAuthApprove handles the consent form. The user signs in on the form itself,
+// so the request cannot be forged without their credentials.
+func (cfg *apiConfig) handlerOAuthApprove(w http.ResponseWriter, req *http.Request) {
+	if err := req.ParseForm(); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		handleErrorResponse(w, http.StatusBadRequest, "Error decoding form")
+		return
+	}
+	params := req.PostForm
+
+	authReq, oauthErr, err := cfg.parseAuthorizeRequest(context.Background(), params)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		handleErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	} else if oauthErr != "" {
+		redirectToClient(w, req, authReq, url.Values{"error": {oauthErr}})
+		return
+	}
+
+	if params.Get("decision") != "allow" {
+		redirectToClient(w, req, authReq, url.Values{"error": {"access_denied"}})
+		return
+	}
+
+	user, status, err := cfg.authenticateConsent(req, params.Get("email"), params.Get("password"), params.Get("totp_code"))
+	if err != nil {
+		renderConsentPage(w, status, authReq, params, err.Error())
+		return
+	}
+
+	code, err := auth.MakeRefreshToken()
+	if err != nil {
+		renderConsentPage(w, http.StatusInternalServerError, authReq, params, "Error making authorization code")
+		return
+	}
+	createOAuthAuthorizationCodeParams := database.CreateOAuthAuthorizationCodeParams{
+		Code:		code,
+		ClientID:	authReq.Client.ID,
+		UserID:		user.ID,
+		RedirectUri:	authReq.RedirectURI,
+		Scope:		authReq.Scope,
+		CodeChallenge:	authReq.CodeChallenge,
+		ExpiresAt:	time.Now().Add(oauthAuthorizationCodeDuration),
+	}
+	if _, err := cfg.db.CreateOAuthAuthorizationCode(context.Background(), createOAuthAuthorizationCodeParams); err != nil {
+		renderConsentPage(w, http.StatusInternalServerError, authReq, params, "Error making authorization code")
+		return
+	}
+
+	redirectToClient(w, req, authReq, url.Values{"code": {code}})
+}
+
+// authenticateConsent checks the credentials typed into the consent page with the same
+// throttling as handlerLogin, and the TOTP code when the user has two-factor enabled.
+// Like any other login it cancels a scheduled account deletion.
+func (cfg *apiConfig) authenticateConsent(req *http.Request, email, password, totpCode string) (database.User, int, error) {
+	ctx := context.Background()
+	accountKey := accountLoginKey(email)
+	ipKey := ipLoginKey(req)
+	if lockedUntil := cfg.loginLockedUntil(ctx, accountKey, ipKey); !lockedUntil.IsZero() {
+		return database.User{}, http.StatusTooManyRequests, errors.New("Too many failed login attempts, try again later")
+	}
+
+	user, err := cfg.db.GetUserByEmail(ctx, email)
+	if err != nil {
+		auth.CheckPasswordHash(cfg.dummyPasswordHash, password)
+	} else {
+		err = auth.CheckPasswordHash(user.HashedPassword, password)
+	}
+
+	if err == nil {
+		totp, totpErr := cfg.db.GetUserTOTP(ctx, user.ID)
+		if totpErr == nil && totp.ConfirmedAt.Valid {
+			step, stepErr := auth.ValidateTOTP(totp.Secret, totpCode, time.Now())
+			if stepErr != nil {
+				err = stepErr
+			} else {
+				useTOTPStepParams := database.UseTOTPStepParams{
+					UserID:		user.ID,
+					LastUsedStep:	step,
+				}
+				rows, useErr := cfg.db.UseTOTPStep(ctx, useTOTPStepParams)
+				if useErr != nil || rows != 1 {
+					err = errors.New("TOTP code already used")
+				}
+			}
+		}
+	}
+
+	if err != nil {
+		if err := cfg.recordLoginFailure(ctx, accountKey, accountLoginThreshold); err != nil {
+			log.Printf("Error recording failed login: %v", err)
+		}
+		if err := cfg.recordLoginFailure(ctx, ipKey, ipLoginThreshold); err != nil {
+			log.Printf("Error recording failed login: %v", err)
+		}
+		return database.User{}, http.StatusUnauthorized, errors.New("Invalid credentials")
+	}
+
+	if err := cfg.db.ClearLoginFailures(ctx, accountKey); err != nil {
+		log.Printf("Error clearing failed logins: %v", err)
+	}
+
+	if user.DeletionScheduledFor.Valid {
+		if err := cfg.db.CancelUserDeletion(ctx, user.ID); err != nil {
+			return database.User{}, http.StatusInternalServerError, errors.New("Error cancelling account deletion")
+		}
+		log.Printf("Cancelled scheduled deletion of user %s after login", user.ID)
+	}
+	return user, 0, nil
+}
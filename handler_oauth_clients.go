@@ -0,0 +1,178 @@
+package main
+
+import (
+	"time"
+	"context"
+	"net/url"
+	"net/http"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/kmilanbanda/chirpy/internal/auth"
+	"github.com/kmilanbanda/chirpy/internal/database"
+)
+
+type oauthClientResponse struct {
+	ClientID	uuid.UUID	`json:"client_id"`
+	ClientSecret	string		`json:"client_secret,omitempty"`
+	CreatedAt	time.Time	`json:"created_at"`
+	Name		string		`json:"name"`
+	RedirectURIs	[]string	`json:"redirect_uris"`
+	Confidential	bool		`json:"confidential"`
+}
+
+func (cfg *apiConfig) handlerCreateOAuthClient(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	type request struct {
+		Name		string		`json:"name"`
+		RedirectURIs	[]string	`json:"redirect_uris"`
+		Confidential	bool		`json:"confidential"`
+	}
+
+	var reqBody request
+	if err := json.NewDecoder(req.Body).Decode(&reqBody); err != nil {
+		handleErrorResponse(w, http.StatusBadRequest, "Error decoding request parameters")
+		return
+	}
+
+	if reqBody.Name == "" {
+		handleErrorResponse(w, http.StatusBadRequest, "Client name is required")
+		return
+	}
+	if len(reqBody.RedirectURIs) == 0 {
+		handleErrorResponse(w, http.StatusBadRequest, "At least one redirect URI is required")
+		return
+	}
+	for _, redirectURI := range reqBody.RedirectURIs {
+		parsed, err := url.Parse(redirectURI)
+		if err != nil || !parsed.IsAbs() || parsed.Fragment != "" {
+			handleErrorResponse(w, http.StatusBadRequest, "Redirect URIs must be absolute URLs without a fragment")
+			return
+		}
+	}
+
+	var clientSecret string
+	var clientSecretHash sql.NullString
+	if reqBody.Confidential {
+		clientSecret, err = auth.MakeRefreshToken()
+		if err != nil {
+			handleErrorResponse(w, http.StatusInternalServerError, "Error making client secret")
+			return
+		}
+		hash, err := cfg.hasher.Hash(clientSecret)
+		if err != nil {
+			handleErrorResponse(w, http.StatusInternalServerError, "Error hashing client secret")
+			return
+		}
+		clientSecretHash = sql.NullString{String: hash, Valid: true}
+	}
+
+	createOAuthClientParams := database.CreateOAuthClientParams{
+		UserID:			userID,
+		Name:			reqBody.Name,
+		ClientSecretHash:	clientSecretHash,
+		RedirectUris:		reqBody.RedirectURIs,
+	}
+	client, err := cfg.db.CreateOAuthClient(context.Background(), createOAuthClientParams)
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error creating client")
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	resp := oauthClientResponse{
+		ClientID:	client.ID,
+		ClientSecret:	clientSecret,
+		CreatedAt:	client.CreatedAt,
+		Name:		client.Name,
+		RedirectURIs:	client.RedirectUris,
+		Confidential:	client.ClientSecretHash.Valid,
+	}
+	dat, _ := json.Marshal(resp)
+	w.Write(dat)
+}
+
+func (cfg *apiConfig) handlerGetOAuthClients(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	clients, err := cfg.db.GetOAuthClientsByUser(context.Background(), userID)
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error getting clients")
+		return
+	}
+
+	resp := []oauthClientResponse{}
+	for _, client := range clients {
+		resp = append(resp, oauthClientResponse{
+			ClientID:	client.ID,
+			CreatedAt:	client.CreatedAt,
+			Name:		client.Name,
+			RedirectURIs:	client.RedirectUris,
+			Confidential:	client.ClientSecretHash.Valid,
+		})
+	}
+
+	w.WriteHeader(http.StatusOK)
+	dat, _ := json.Marshal(resp)
+	w.Write(dat)
+}
+
+func (cfg *apiConfig) handlerDeleteOAuthClient(w http.ResponseWriter, req *http.Request) {
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	clientID, err := uuid.Parse(req.PathValue("clientID"))
+	if err != nil {
+		handleErrorResponse(w, http.StatusNotFound, "Error parsing client ID")
+		return
+	}
+
+	client, err := cfg.db.GetOAuthClient(context.Background(), clientID)
+	if err != nil || client.UserID != userID {
+		handleErrorResponse(w, http.StatusNotFound, "Error finding client")
+		return
+	}
+
+	deleteOAuthClientParams := database.DeleteOAuthClientParams{
+		ID:	clientID,
+		UserID:	userID,
+	}
+	if err := cfg.db.DeleteOAuthClient(context.Background(), deleteOAuthClientParams); err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error deleting client")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"log"
+	"time"
+	"errors"
+	"context"
+	"net/url"
+	"net/http"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/kmilanbanda/chirpy/internal/auth"
+	"github.com/kmilanbanda/chirpy/internal/oidc"
+	"github.com/kmilanbanda/chirpy/internal/database"
+)
+
+const (
+	oauthAccessTokenDuration	= time.Hour
+	oauthRefreshTokenDuration	= time.Hour * 24 * 60
+)
+
+// authenticateOAuthClient identifies the client from HTTP basic auth or the form body.
+// Confidential clients must send their secret; public clients only send their ID.
+func (cfg *apiConfig) authenticateOAuthClient(req *http.Request) (database.OauthClient, error) {
+	rawClientID, clientSecret, ok := req.BasicAuth()
+	if ok {
+		rawClientID, _ = url.QueryUnescape(rawClientID)
+		clientSecret, _ = url.QueryUnescape(clientSecret)
+	} else {
+		rawClientID = req.PostForm.Get("client_id")
+		clientSecret = req.PostForm.Get("client_secret")
+	}
+
+	clientID, err := uuid.Parse(rawClientID)
+	if err != nil {
+		return database.OauthClient{}, errors.New("Unknown client")
+	}
+	client, err := cfg.db.GetOAuthClient(context.Background(), clientID)
+	if err != nil {
+		return database.OauthClient{}, errors.New("Unknown client")
+	}
+
+	if client.ClientSecretHash.Valid {
+		if err := auth.CheckPasswordHash(client.ClientSecretHash.String, clientSecret); err != nil {
+			return database.OauthClient{}, errors.New("Invalid client secret")
+		}
+	}
+	return client, nil
+}
+
+// handlerOAuthToken exchanges authorization codes and refresh tokens for access tokens
+func (cfg *apiConfig) handlerOAuthToken(w http.ResponseWriter, req *http.Request) {
+	if err := req.ParseForm(); err != nil {
+		handleOAuthErrorResponse(w, http.StatusBadRequest, "invalid_request", "Error decoding form")
+		return
+	}
+
+	client, err := cfg.authenticateOAuthClient(req)
+	if err != nil {
+		handleOAuthErrorResponse(w, http.StatusUnauthorized, "invalid_client", err.Error())
+		return
+	}
+
+	switch req.PostForm.Get("grant_type") {
+	case "authorization_code":
+		cfg.exchangeAuthorizationCode(w, req, client)
+	case "refresh_token":
+		cfg.exchangeClientRefreshToken(w, req, client)
+	default:
+		handleOAuthErrorResponse(w, http.StatusBadRequest, "unsupported_grant_type", "")
+	}
+}
+
+func (cfg *apiConfig) exchangeAuthorizationCode(w http.ResponseWriter, req *http.Request, client database.OauthClient) {
+	// Codes are deleted as they are read so each one works exactly once
+	code, err := cfg.db.ConsumeOAuthAuthorizationCode(context.Background(), req.PostForm.Get("code"))
+	if err != nil {
+		handleOAuthErrorResponse(w, http.StatusBadRequest, "invalid_grant", "Unknown or already used authorization code")
+		return
+	} else if code.ClientID != client.ID {
+		handleOAuthErrorResponse(w, http.StatusBadRequest, "invalid_grant", "Authorization code was issued to another client")
+		return
+	} else if code.RedirectUri != req.PostForm.Get("redirect_uri") {
+		handleOAuthErrorResponse(w, http.StatusBadRequest, "invalid_grant", "Redirect URI does not match the authorization request")
+		return
+	} else if time.Now().After(code.ExpiresAt) {
+		handleOAuthErrorResponse(w, http.StatusBadRequest, "invalid_grant", "Authorization code expired")
+		return
+	}
+
+	if code.CodeChallenge != "" && oidc.PKCEChallenge(req.PostForm.Get("code_verifier")) != code.CodeChallenge {
+		handleOAuthErrorResponse(w, http.StatusBadRequest, "invalid_grant", "Code verifier does not match the code challenge")
+		return
+	}
+
+	cfg.writeOAuthTokenResponse(w, code.UserID, client.ID, code.Scope)
+}
+
+// exchangeClientRefreshToken rotates the refresh token, so a leaked token stops working
+// the next time the real client uses it
+func (cfg *apiConfig) exchangeClientRefreshToken(w http.ResponseWriter, req *http.Request, client database.OauthClient) {
+	refreshToken, err := cfg.db.GetRefreshTokenByToken(context.Background(), req.PostForm.Get("refresh_token"))
+	if err != nil || refreshToken.ClientID.UUID != client.ID {
+		handleOAuthErrorResponse(w, http.StatusBadRequest, "invalid_grant", "Unknown refresh token")
+		return
+	} else if refreshToken.RevokedAt.Valid {
+		handleOAuthErrorResponse(w, http.StatusBadRequest, "invalid_grant", "Refresh token revoked")
+		return
+	} else if time.Now().After(refreshToken.ExpiresAt) {
+		handleOAuthErrorResponse(w, http.StatusBadRequest, "invalid_grant", "Refresh token expired")
+		return
+	}
+
+	if err := cfg.db.RevokeToken(context.Background(), refreshToken.Token); err != nil {
+		handleOAuthErrorResponse(w, http.StatusInternalServerError, "server_error", "Error revoking refresh token")
+		return
+	}
+
+	cfg.writeOAuthTokenResponse(w, refreshToken.UserID, client.ID, refreshToken.Scope)
+}
+
+func (cfg *apiConfig) writeOAuthTokenResponse(w http.ResponseWriter, userID, clientID uuid.UUID, scope string) {
+	accessToken, err := auth.MakeScopedJWT(userID, cfg.secret, oauthAccessTokenDuration, clientID.String(), scope)
+	if err != nil {
+		handleOAuthErrorResponse(w, http.StatusInternalServerError, "server_error", "Error making access token")
+		return
+	}
+	refreshToken, err := auth.MakeRefreshToken()
+	if err != nil {
+		handleOAuthErrorResponse(w, http.StatusInternalServerError, "server_error", "Error making refresh token")
+		return
+	}
+	createClientRefreshTokenParams := database.CreateClientRefreshTokenParams{
+		Token:		refreshToken,
+		UserID:		userID,
+		ExpiresAt:	time.Now().Add(oauthRefreshTokenDuration),
+		ClientID:	uuid.NullUUID{UUID: clientID, Valid: true},
+		Scope:		scope,
+	}
+	if _, err := cfg.db.CreateClientRefreshToken(context.Background(), createClientRefreshTokenParams); err != nil {
+		handleOAuthErrorResponse(w, http.StatusInternalServerError, "server_error", "Error saving refresh token")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	resp := struct{
+		AccessToken	string	`json:"access_token"`
+		TokenType	string	`json:"token_type"`
+		ExpiresIn	int	`json:"expires_in"`
+		RefreshToken	string	`json:"refresh_token"`
+		Scope		string	`json:"scope"`
+	}{
+		AccessToken:	accessToken,
+		TokenType:	"Bearer",
+		ExpiresIn:	int(oauthAccessTokenDuration.Seconds()),
+		RefreshToken:	refreshToken,
+		Scope:		scope,
+	}
+	dat, _ := json.Marshal(resp)
+	w.Write(dat)
+}
+
+// handlerOAuthRevoke implements RFC 7009. It answers 200 for unknown tokens too, so it
+// cannot be used to probe for valid ones. Access tokens are short lived and not stored,
+// so only refresh tokens are actually revoked.
+func (cfg *apiConfig) handlerOAuthRevoke(w http.ResponseWriter, req *http.Request) {
+	if err := req.ParseForm(); err != nil {
+		handleOAuthErrorResponse(w, http.StatusBadRequest, "invalid_request", "Error decoding form")
+		return
+	}
+
+	client, err := cfg.authenticateOAuthClient(req)
+	if err != nil {
+		handleOAuthErrorResponse(w, http.StatusUnauthorized, "invalid_client", err.Error())
+		return
+	}
+
+	refreshToken, err := cfg.db.GetRefreshTokenByToken(context.Background(), req.PostForm.Get("token"))
+	if err == nil && refreshToken.ClientID.UUID == client.ID && !refreshToken.RevokedAt.Valid {
+		if err := cfg.db.RevokeToken(context.Background(), refreshToken.Token); err != nil {
+			log.Printf("Error revoking refresh token: %v", err)
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// handlerOAuthIntrospect implements RFC 7662 for the calling client's own tokens
+func (cfg *apiConfig) handlerOAuthIntrospect(w http.ResponseWriter, req *http.Request) {
+	if err := req.ParseForm(); err != nil {
+		handleOAuthErrorResponse(w, http.StatusBadRequest, "invalid_request", "Error decoding form")
+		return
+	}
+
+	client, err := cfg.authenticateOAuthClient(req)
+	if err != nil {
+		handleOAuthErrorResponse(w, http.StatusUnauthorized, "invalid_client", err.Error())
+		return
+	}
+
+	type response struct {
+		Active		bool		`json:"active"`
+		Scope		string		`json:"scope,omitempty"`
+		ClientID	string		`json:"client_id,omitempty"`
+		Sub		string		`json:"sub,omitempty"`
+		Exp		int64		`json:"exp,omitempty"`
+		TokenType	string		`json:"token_type,omitempty"`
+	}
+
+	token := req.PostForm.Get("token")
+	resp := response{}
+	if refreshToken, err := cfg.db.GetRefreshTokenByToken(context.Background(), token); err == nil {
+		if refreshToken.ClientID.UUID == client.ID && !refreshToken.RevokedAt.Valid && time.Now().Before(refreshToken.ExpiresAt) {
+			resp = response{
+				Active:		true,
+				Scope:		refreshToken.Scope,
+				ClientID:	client.ID.String(),
+				Sub:		refreshToken.UserID.String(),
+				Exp:		refreshToken.ExpiresAt.Unix(),
+				TokenType:	"refresh_token",
+			}
+		}
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		handleOAuthErrorResponse(w, http.StatusInternalServerError, "server_error", "Error finding token")
+		return
+	} else if claims, userID, err := auth.ParseAccessToken(token, cfg.secret); err == nil && claims.ClientID == client.ID.String() {
+		resp = response{
+			Active:		true,
+			Scope:		claims.Scope,
+			ClientID:	claims.ClientID,
+			Sub:		userID.String(),
+			TokenType:	"access_token",
+		}
+		if claims.ExpiresAt != nil {
+			resp.Exp = claims.ExpiresAt.Unix()
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	dat, _ := json.Marshal(resp)
+	w.Write(dat)
+}
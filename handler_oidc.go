@@ -0,0 +1,160 @@
+package main
+
+import (
+	"log"
+	"time"
+	"context"
+	"net/http"
+	"database/sql"
+	"errors"
+
+	"github.com/kmilanbanda/chirpy/internal/oidc"
+	"github.com/kmilanbanda/chirpy/internal/database"
+)
+
+const oidcLoginStateDuration = time.Minute * 10
+
+// handlerOIDCLogin starts an authorization code flow with PKCE by redirecting the
+// browser to the identity provider
+func (cfg *apiConfig) handlerOIDCLogin(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	provider, ok := cfg.oidcProviders[req.PathValue("provider")]
+	if !ok {
+		handleErrorResponse(w, http.StatusNotFound, "Unknown identity provider")
+		return
+	}
+
+	state, err := oidc.RandomString()
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error starting login")
+		return
+	}
+	nonce, err := oidc.RandomString()
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error starting login")
+		return
+	}
+	codeVerifier, err := oidc.RandomString()
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error starting login")
+		return
+	}
+
+	authURL, err := provider.AuthCodeURL(context.Background(), state, nonce, codeVerifier)
+	if err != nil {
+		log.Printf("Error contacting identity provider %s: %v", provider.Name(), err)
+		handleErrorResponse(w, http.StatusBadGateway, "Error contacting identity provider")
+		return
+	}
+
+	createOIDCLoginStateParams := database.CreateOIDCLoginStateParams{
+		State:		state,
+		Provider:	provider.Name(),
+		Nonce:		nonce,
+		CodeVerifier:	codeVerifier,
+		ExpiresAt:	time.Now().Add(oidcLoginStateDuration),
+	}
+	if _, err := cfg.db.CreateOIDCLoginState(context.Background(), createOIDCLoginStateParams); err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error starting login")
+		return
+	}
+	if err := cfg.db.DeleteExpiredOIDCLoginStates(context.Background()); err != nil {
+		log.Printf("Error deleting expired login states: %v", err)
+	}
+
+	http.Redirect(w, req, authURL, http.StatusFound)
+}
+
+// handlerOIDCCallback finishes the flow, links the external identity to a Chirpy user
+// and logs them in the same way as handlerLogin
+func (cfg *apiConfig) handlerOIDCCallback(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	provider, ok := cfg.oidcProviders[req.PathValue("provider")]
+	if !ok {
+		handleErrorResponse(w, http.StatusNotFound, "Unknown identity provider")
+		return
+	}
+
+	query := req.URL.Query()
+	if errorCode := query.Get("error"); errorCode != "" {
+		handleErrorResponse(w, http.StatusUnauthorized, "Identity provider returned an error: "+errorCode)
+		return
+	}
+
+	loginState, err := cfg.db.ConsumeOIDCLoginState(context.Background(), query.Get("state"))
+	if err != nil {
+		handleErrorResponse(w, http.StatusBadRequest, "Unknown or already used login state")
+		return
+	} else if loginState.Provider != provider.Name() {
+		handleErrorResponse(w, http.StatusBadRequest, "Login state belongs to another provider")
+		return
+	} else if time.Now().After(loginState.ExpiresAt) {
+		handleErrorResponse(w, http.StatusBadRequest, "Login state expired")
+		return
+	}
+
+	claims, err := provider.Exchange(context.Background(), query.Get("code"), loginState.CodeVerifier, loginState.Nonce)
+	if err != nil {
+		log.Printf("Error completing login with %s: %v", provider.Name(), err)
+		handleErrorResponse(w, http.StatusUnauthorized, "Error verifying identity")
+		return
+	}
+
+	user, status, err := cfg.userForIdentity(context.Background(), provider.Name(), claims)
+	if err != nil {
+		handleErrorResponse(w, status, err.Error())
+		return
+	}
+
+	cfg.completeLogin(w, user)
+}
+
+// userForIdentity finds the user already linked to the identity, links it to the user
+// with the same verified email, or creates a new user
+func (cfg *apiConfig) userForIdentity(ctx context.Context, providerName string, claims *oidc.IDTokenClaims) (database.User, int, error) {
+	getUserIdentityParams := database.GetUserIdentityParams{
+		Provider:	providerName,
+		Subject:	claims.Subject,
+	}
+	identity, err := cfg.db.GetUserIdentity(ctx, getUserIdentityParams)
+	if err == nil {
+		user, err := cfg.db.GetUserByID(ctx, identity.UserID)
+		if err != nil {
+			return database.User{}, http.StatusInternalServerError, errors.New("Error finding linked user")
+		}
+		return user, 0, nil
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return database.User{}, http.StatusInternalServerError, errors.New("Error finding linked identity")
+	}
+
+	if claims.Email == "" || !claims.IsEmailVerified() {
+		return database.User{}, http.StatusForbidden, errors.New("Identity provider did not return a verified email address")
+	}
+
+	user, err := cfg.db.GetUserByEmail(ctx, claims.Email)
+	if errors.Is(err, sql.ErrNoRows) {
+		user, err = cfg.db.CreateUserWithVerifiedEmail(ctx, claims.Email)
+		if err != nil {
+			return database.User{}, http.StatusInternalServerError, errors.New("Error creating user")
+		}
+	} else if err != nil {
+		return database.User{}, http.StatusInternalServerError, errors.New("Error finding user")
+	} else if !user.EmailVerifiedAt.Valid {
+		// Linking to an unverified account would hand it to whoever registered the address first
+		return database.User{}, http.StatusConflict, errors.New("Verify the email address of your existing account before signing in with this provider")
+	}
+
+	createUserIdentityParams := database.CreateUserIdentityParams{
+		UserID:		user.ID,
+		Provider:	providerName,
+		Subject:	claims.Subject,
+		Email:		claims.Email,
+	}
+	if _, err := cfg.db.CreateUserIdentity(ctx, createUserIdentityParams); err != nil {
+		return database.User{}, http.StatusInternalServerError, errors.New("Error linking identity")
+	}
+
+	return user, 0, nil
+}
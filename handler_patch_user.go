@@ -0,0 +1,222 @@
+package main
+
+import (
+	"log"
+	"time"
+	"context"
+	"strings"
+	"net/http"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/kmilanbanda/chirpy/internal/database"
+	"github.com/kmilanbanda/chirpy/internal/auth"
+)
+
+// handlerPatchUser only changes the fields present in the request body. Changing
+// the email or password requires the user's current password; profile fields do not.
+func (cfg *apiConfig) handlerPatchUser(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	type request struct{
+		Email		*string	`json:"email"`
+		Password	*string	`json:"password"`
+		CurrentPassword	string	`json:"current_password"`
+		Handle		*string	`json:"handle"`
+		DisplayName	*string	`json:"display_name"`
+		Bio		*string	`json:"bio"`
+		Location	*string	`json:"location"`
+		Website		*string	`json:"website"`
+		Protected	*bool	`json:"protected"`
+	}
+
+	var reqBody request
+	if err = json.NewDecoder(req.Body).Decode(&reqBody); err != nil {
+		handleErrorResponse(w, http.StatusBadRequest, "Error decoding request parameters")
+		return
+	}
+
+	currentUser, err := cfg.db.GetUserByID(context.Background(), userID)
+	if err != nil {
+		handleErrorResponse(w, http.StatusNotFound, "Error finding user")
+		return
+	}
+
+	if reqBody.Email != nil || reqBody.Password != nil {
+		if reqBody.CurrentPassword == "" {
+			handleErrorResponse(w, http.StatusBadRequest, "Current password is required to change email or password")
+			return
+		}
+		if err := auth.CheckPasswordHash(currentUser.HashedPassword, reqBody.CurrentPassword); err != nil {
+			handleErrorResponse(w, http.StatusForbidden, "Current password is incorrect")
+			return
+		}
+	}
+
+	updateUserParams := database.UpdateUserParams{
+		ID:		userID,
+		Email:		currentUser.Email,
+		HashedPassword:	currentUser.HashedPassword,
+	}
+
+	if reqBody.Email != nil {
+		if err := validateEmail(*reqBody.Email); err != nil {
+			handleErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		updateUserParams.Email = *reqBody.Email
+	}
+
+	if reqBody.Password != nil {
+		if err := cfg.passwordPolicy.Validate(*reqBody.Password, updateUserParams.Email); err != nil {
+			handlePasswordPolicyError(w, err)
+			return
+		}
+		hashedPassword, err := cfg.hasher.Hash(*reqBody.Password)
+		if err != nil {
+			handleErrorResponse(w, http.StatusInternalServerError, "Error hashing password")
+			return
+		}
+		updateUserParams.HashedPassword = hashedPassword
+	}
+
+	updateUserProfileParams := database.UpdateUserProfileParams{
+		ID:		userID,
+		Handle:		currentUser.Handle,
+		DisplayName:	currentUser.DisplayName,
+		Bio:		currentUser.Bio,
+		Location:	currentUser.Location,
+		Website:	currentUser.Website,
+	}
+	profileChanged := false
+
+	if reqBody.Handle != nil {
+		if err := validateHandle(*reqBody.Handle); err != nil {
+			handleErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		updateUserProfileParams.Handle = *reqBody.Handle
+		profileChanged = true
+	}
+
+	profileFields := []struct{
+		name		string
+		value		*string
+		maxLength	int
+		target		*string
+	}{
+		{"Display name", reqBody.DisplayName, maxDisplayNameLength, &updateUserProfileParams.DisplayName},
+		{"Bio", reqBody.Bio, maxBioLength, &updateUserProfileParams.Bio},
+		{"Location", reqBody.Location, maxLocationLength, &updateUserProfileParams.Location},
+		{"Website", reqBody.Website, maxWebsiteLength, &updateUserProfileParams.Website},
+	}
+	for _, field := range profileFields {
+		if field.value == nil {
+			continue
+		}
+		value := strings.TrimSpace(*field.value)
+		if err := validateProfileField(field.name, value, field.maxLength); err != nil {
+			handleErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		*field.target = value
+		profileChanged = true
+	}
+	if err := validateWebsite(updateUserProfileParams.Website); err != nil {
+		handleErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Everything is validated above, so the request either saves all of its changes or none
+	var user database.User
+	err = cfg.inTx(context.Background(), func(q *database.Queries) error {
+		var err error
+		user, err = q.UpdateUser(context.Background(), updateUserParams)
+		if err != nil {
+			return err
+		}
+		if profileChanged {
+			user, err = q.UpdateUserProfile(context.Background(), updateUserProfileParams)
+			if err != nil {
+				return err
+			}
+		}
+		if reqBody.Protected != nil && *reqBody.Protected != user.Protected {
+			updateUserProtectedParams := database.UpdateUserProtectedParams{
+				ID:		userID,
+				Protected:	*reqBody.Protected,
+			}
+			user, err = q.UpdateUserProtected(context.Background(), updateUserProtectedParams)
+			if err != nil {
+				return err
+			}
+			// Nobody is left waiting once the account is public again
+			if !user.Protected {
+				return q.ApproveAllFollowRequests(context.Background(), userID)
+			}
+		}
+		return nil
+	})
+	if isUniqueViolation(err, usersEmailConstraint) {
+		handleErrorResponse(w, http.StatusConflict, "Email address is already in use")
+		return
+	} else if isUniqueViolation(err, usersHandleConstraint) {
+		handleErrorResponse(w, http.StatusConflict, "Handle is already taken")
+		return
+	} else if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error updating user")
+		return
+	}
+
+	if user.Email != currentUser.Email {
+		if err := cfg.sendVerificationEmail(context.Background(), user); err != nil {
+			log.Printf("Error sending verification email to user %s: %v", user.ID, err)
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	resp := struct{
+		ID		uuid.UUID 	`json:"id"`
+		CreatedAt	time.Time	`json:"created_at"`
+		UpdatedAt	time.Time	`json:"updated_at"`
+		Email		string		`json:"email"`
+		IsChirpyRed	bool		`json:"is_chirpy_red"`
+		IsEmailVerified	bool		`json:"is_email_verified"`
+		Handle		string		`json:"handle"`
+		DisplayName	string		`json:"display_name"`
+		Bio		string		`json:"bio"`
+		Location	string		`json:"location"`
+		Website		string		`json:"website"`
+		AvatarURL	string		`json:"avatar_url"`
+		HeaderURL	string		`json:"header_url"`
+		Protected	bool		`json:"protected"`
+	}{
+		ID:		user.ID,
+		CreatedAt:	user.CreatedAt,
+		UpdatedAt:	user.UpdatedAt,
+		Email:		user.Email,
+		IsChirpyRed:	user.IsChirpyRed,
+		IsEmailVerified:	user.EmailVerifiedAt.Valid,
+		Handle:		user.Handle,
+		DisplayName:	user.DisplayName,
+		Bio:		user.Bio,
+		Location:	user.Location,
+		Website:	user.Website,
+		AvatarURL:	cfg.blobURL(user.AvatarKey),
+		HeaderURL:	cfg.blobURL(user.HeaderKey),
+		Protected:	user.Protected,
+	}
+	dat, _ := json.Marshal(resp)
+	w.Write(dat)
+}
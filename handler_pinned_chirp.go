@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/kmilanbanda/chirpy/internal/auth"
+	"github.com/kmilanbanda/chirpy/internal/database"
+)
+
+// handlerPinChirp pins one of the user's own published chirps to the top of their
+// profile, replacing any chirp pinned before
+func (cfg *apiConfig) handlerPinChirp(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateScopedJWT(token, cfg.secret, "chirps:write")
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	type request struct {
+		ChirpID	uuid.UUID	`json:"chirp_id"`
+	}
+
+	var reqBody request
+	if err := json.NewDecoder(req.Body).Decode(&reqBody); err != nil {
+		handleErrorResponse(w, http.StatusBadRequest, "Error decoding request parameters")
+		return
+	}
+
+	getChirpParams := database.GetChirpParams{
+		ID:		reqBody.ChirpID,
+		ViewerID:	userID,
+	}
+	chirp, err := cfg.db.GetChirp(context.Background(), getChirpParams)
+	if err != nil {
+		handleErrorResponse(w, http.StatusNotFound, "Error finding chirp")
+		return
+	}
+	if chirp.UserID != userID {
+		handleErrorResponse(w, http.StatusForbidden, "You can only pin your own chirps")
+		return
+	}
+	if !chirp.PublishedAt.Valid {
+		handleErrorResponse(w, http.StatusConflict, "Scheduled chirps cannot be pinned")
+		return
+	}
+
+	updateUserPinnedChirpParams := database.UpdateUserPinnedChirpParams{
+		ID:		userID,
+		PinnedChirpID:	uuid.NullUUID{UUID: chirp.ID, Valid: true},
+	}
+	if _, err := cfg.db.UpdateUserPinnedChirp(context.Background(), updateUserPinnedChirpParams); err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error pinning chirp")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUnpinChirp(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateScopedJWT(token, cfg.secret, "chirps:write")
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	updateUserPinnedChirpParams := database.UpdateUserPinnedChirpParams{
+		ID:	userID,
+	}
+	if _, err := cfg.db.UpdateUserPinnedChirp(context.Background(), updateUserPinnedChirpParams); err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error unpinning chirp")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// pinFirst moves the author's pinned chirp, if it is in the listing, to the front and
+// marks it as pinned
+func pinFirst(chirps []chirpResponse, pinnedChirpID uuid.NullUUID) []chirpResponse {
+	if !pinnedChirpID.Valid {
+		return chirps
+	}
+	for i, chirp := range chirps {
+		if chirp.ID != pinnedChirpID.UUID {
+			continue
+		}
+		chirp.Pinned = true
+		copy(chirps[1:i+1], chirps[:i])
+		chirps[0] = chirp
+		break
+	}
+	return chirps
+}
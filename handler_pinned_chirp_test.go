@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestPinFirst(t *testing.T) {
+	ids := []uuid.UUID{uuid.New(), uuid.New(), uuid.New()}
+	tests := []struct {
+		name	string
+		pinned	uuid.NullUUID
+		want	[]uuid.UUID
+	}{
+		{"nothing pinned", uuid.NullUUID{}, ids},
+		{"pinned already first", uuid.NullUUID{UUID: ids[0], Valid: true}, ids},
+		{"pinned in the middle", uuid.NullUUID{UUID: ids[1], Valid: true}, []uuid.UUID{ids[1], ids[0], ids[2]}},
+		{"pinned last", uuid.NullUUID{UUID: ids[2], Valid: true}, []uuid.UUID{ids[2], ids[0], ids[1]}},
+		{"pinned not listed", uuid.NullUUID{UUID: uuid.New(), Valid: true}, ids},
+	}
+	for _, test := range tests {
+		chirps := make([]chirpResponse, len(ids))
+		for i, id := range ids {
+			chirps[i] = chirpResponse{ID: id}
+		}
+
+		got := pinFirst(chirps, test.pinned)
+		if len(got) != len(test.want) {
+			t.Fatalf("%s: expected %d chirps, got %d", test.name, len(test.want), len(got))
+		}
+		for i, chirp := range got {
+			if chirp.ID != test.want[i] {
+				t.Errorf("%s: expected chirp %d to be %v, got %v", test.name, i, test.want[i], chirp.ID)
+			}
+			wantPinned := test.pinned.Valid && chirp.ID == test.pinned.UUID
+			if chirp.Pinned != wantPinned {
+				t.Errorf("%s: expected chirp %d pinned to be %v", test.name, i, wantPinned)
+			}
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"fmt"
+	"time"
+	"context"
+	"strings"
+	"net/http"
+	"unicode/utf8"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/kmilanbanda/chirpy/internal/auth"
+	"github.com/kmilanbanda/chirpy/internal/database"
+)
+
+const (
+	minPollOptions		= 2
+	maxPollOptions		= 4
+	maxPollOptionLength	= 25
+	minPollDuration		= time.Minute * 5
+	maxPollDuration		= time.Hour * 24 * 7
+)
+
+type pollRequest struct {
+	Options		[]string	`json:"options"`
+	ClosesAt	time.Time	`json:"closes_at"`
+}
+
+// validate trims the options and checks them and the closing time
+func (poll *pollRequest) validate(now time.Time) error {
+	if len(poll.Options) < minPollOptions || len(poll.Options) > maxPollOptions {
+		return fmt.Errorf("A poll must have %d to %d options", minPollOptions, maxPollOptions)
+	}
+	seen := map[string]struct{}{}
+	for i, option := range poll.Options {
+		option = strings.TrimSpace(option)
+		if option == "" || utf8.RuneCountInString(option) > maxPollOptionLength {
+			return fmt.Errorf("Poll options must be 1 to %d characters", maxPollOptionLength)
+		}
+		if _, duplicate := seen[strings.ToLower(option)]; duplicate {
+			return fmt.Errorf("Poll options must be different")
+		}
+		seen[strings.ToLower(option)] = struct{}{}
+		poll.Options[i] = option
+	}
+
+	duration := poll.ClosesAt.Sub(now)
+	if duration < minPollDuration || duration > maxPollDuration {
+		return fmt.Errorf("A poll must close between %v and %v from now", minPollDuration, maxPollDuration)
+	}
+	return nil
+}
+
+func createPoll(ctx context.Context, q *database.Queries, chirpID uuid.UUID, poll pollRequest) error {
+	createPollParams := database.CreatePollParams{
+		ChirpID:	chirpID,
+		ClosesAt:	poll.ClosesAt.UTC(),
+	}
+	created, err := q.CreatePoll(ctx, createPollParams)
+	if err != nil {
+		return err
+	}
+
+	for i, option := range poll.Options {
+		createPollOptionParams := database.CreatePollOptionParams{
+			PollID:		created.ID,
+			Position:	int32(i),
+			Label:		option,
+		}
+		if _, err := q.CreatePollOption(ctx, createPollOptionParams); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type pollOptionResponse struct {
+	ID	uuid.UUID	`json:"id"`
+	Label	string		`json:"label"`
+	Votes	*int64		`json:"votes"`
+}
+
+// pollResponse hides the counts until the viewer has voted or the poll has closed,
+// so early results do not sway anyone
+type pollResponse struct {
+	ID		uuid.UUID		`json:"id"`
+	ClosesAt	time.Time		`json:"closes_at"`
+	Closed		bool			`json:"closed"`
+	ResultsVisible	bool			`json:"results_visible"`
+	TotalVotes	*int64			`json:"total_votes"`
+	VotedOptionID	*uuid.UUID		`json:"voted_option_id"`
+	Options		[]pollOptionResponse	`json:"options"`
+}
+
+// pollResponses loads the polls of the given chirps as seen by viewerID, keyed by chirp ID
+func (cfg *apiConfig) pollResponses(ctx context.Context, viewerID uuid.NullUUID, chirpIDs []uuid.UUID) (map[uuid.UUID]*pollResponse, error) {
+	responses := map[uuid.UUID]*pollResponse{}
+	polls, err := cfg.db.GetPollsForChirps(ctx, chirpIDs)
+	if err != nil || len(polls) == 0 {
+		return responses, err
+	}
+
+	pollIDs := make([]uuid.UUID, len(polls))
+	for i, poll := range polls {
+		pollIDs[i] = poll.ID
+	}
+
+	votedOption := map[uuid.UUID]uuid.UUID{}
+	if viewerID.Valid {
+		getUserPollVotesParams := database.GetUserPollVotesParams{
+			UserID:		viewerID.UUID,
+			PollIds:	pollIDs,
+		}
+		votes, err := cfg.db.GetUserPollVotes(ctx, getUserPollVotesParams)
+		if err != nil {
+			return nil, err
+		}
+		for _, vote := range votes {
+			votedOption[vote.PollID] = vote.OptionID
+		}
+	}
+
+	results, err := cfg.db.GetPollResults(ctx, pollIDs)
+	if err != nil {
+		return nil, err
+	}
+	optionsByPoll := map[uuid.UUID][]database.GetPollResultsRow{}
+	for _, result := range results {
+		optionsByPoll[result.PollID] = append(optionsByPoll[result.PollID], result)
+	}
+
+	now := time.Now()
+	for _, poll := range polls {
+		resp := &pollResponse{
+			ID:		poll.ID,
+			ClosesAt:	poll.ClosesAt,
+			Closed:		!now.Before(poll.ClosesAt),
+			Options:	[]pollOptionResponse{},
+		}
+		if optionID, voted := votedOption[poll.ID]; voted {
+			resp.VotedOptionID = &optionID
+		}
+		resp.ResultsVisible = resp.Closed || resp.VotedOptionID != nil
+
+		var total int64
+		for _, option := range optionsByPoll[poll.ID] {
+			optionResp := pollOptionResponse{
+				ID:	option.ID,
+				Label:	option.Label,
+			}
+			if resp.ResultsVisible {
+				votes := option.Votes
+				optionResp.Votes = &votes
+			}
+			total += option.Votes
+			resp.Options = append(resp.Options, optionResp)
+		}
+		if resp.ResultsVisible {
+			resp.TotalVotes = &total
+		}
+		responses[poll.ChirpID] = resp
+	}
+	return responses, nil
+}
+
+// handlerVotePoll records the user's vote. Votes are final: there is one per user
+// per poll and it cannot be changed.
+func (cfg *apiConfig) handlerVotePoll(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateScopedJWT(token, cfg.secret, "chirps:write")
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	chirpID, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		handleErrorResponse(w, http.StatusNotFound, "Error finding poll")
+		return
+	}
+
+	type request struct {
+		OptionID	uuid.UUID	`json:"option_id"`
+	}
+
+	var reqBody request
+	if err := json.NewDecoder(req.Body).Decode(&reqBody); err != nil {
+		handleErrorResponse(w, http.StatusBadRequest, "Error decoding request parameters")
+		return
+	}
+
+	getChirpParams := database.GetChirpParams{
+		ID:		chirpID,
+		ViewerID:	userID,
+	}
+	chirp, err := cfg.db.GetChirp(context.Background(), getChirpParams)
+	if err != nil {
+		handleErrorResponse(w, http.StatusNotFound, "Error finding poll")
+		return
+	}
+
+	poll, err := cfg.db.GetPollByChirp(context.Background(), chirpID)
+	if err != nil {
+		handleErrorResponse(w, http.StatusNotFound, "Error finding poll")
+		return
+	}
+	if !chirp.PublishedAt.Valid {
+		handleErrorResponse(w, http.StatusConflict, "Poll opens when the chirp is published")
+		return
+	}
+	if !time.Now().Before(poll.ClosesAt) {
+		handleErrorResponse(w, http.StatusConflict, "Poll is closed")
+		return
+	}
+
+	option, err := cfg.db.GetPollOption(context.Background(), reqBody.OptionID)
+	if err != nil || option.PollID != poll.ID {
+		handleErrorResponse(w, http.StatusBadRequest, "Option is not part of this poll")
+		return
+	}
+
+	createPollVoteParams := database.CreatePollVoteParams{
+		PollID:		poll.ID,
+		UserID:		userID,
+		OptionID:	option.ID,
+	}
+	rows, err := cfg.db.CreatePollVote(context.Background(), createPollVoteParams)
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error saving vote")
+		return
+	}
+	if rows == 0 {
+		handleErrorResponse(w, http.StatusConflict, "You have already voted in this poll")
+		return
+	}
+
+	polls, err := cfg.pollResponses(context.Background(), uuid.NullUUID{UUID: userID, Valid: true}, []uuid.UUID{chirpID})
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error getting poll results")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	dat, _ := json.Marshal(polls[chirpID])
+	w.Write(dat)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"time"
+	"slices"
+	"testing"
+)
+
+func TestPollRequestValidate(t *testing.T) {
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name		string
+		options		[]string
+		closesAt	time.Time
+		wantOptions	[]string
+		wantErr		bool
+	}{
+		{"valid", []string{" Yes ", "No"}, now.Add(time.Hour), []string{"Yes", "No"}, false},
+		{"shortest duration", []string{"a", "b"}, now.Add(minPollDuration), []string{"a", "b"}, false},
+		{"longest duration", []string{"a", "b", "c", "d"}, now.Add(maxPollDuration), []string{"a", "b", "c", "d"}, false},
+		{"one option", []string{"Yes"}, now.Add(time.Hour), nil, true},
+		{"too many options", []string{"a", "b", "c", "d", "e"}, now.Add(time.Hour), nil, true},
+		{"blank option", []string{"Yes", "  "}, now.Add(time.Hour), nil, true},
+		{"long option", []string{"Yes", "this option is far too long to fit"}, now.Add(time.Hour), nil, true},
+		{"duplicate options", []string{"Yes", " yes"}, now.Add(time.Hour), nil, true},
+		{"closes too soon", []string{"a", "b"}, now.Add(minPollDuration - time.Second), nil, true},
+		{"closes too late", []string{"a", "b"}, now.Add(maxPollDuration + time.Second), nil, true},
+		{"already closed", []string{"a", "b"}, now.Add(-time.Hour), nil, true},
+	}
+	for _, test := range tests {
+		poll := pollRequest{Options: test.options, ClosesAt: test.closesAt}
+		err := poll.validate(now)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected an error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if !slices.Equal(poll.Options, test.wantOptions) {
+			t.Errorf("%s: expected options %q, got %q", test.name, test.wantOptions, poll.Options)
+		}
+	}
+}
@@ -3,9 +3,13 @@ package main
 import (
 	"encoding/json"
 	"net/http"
+	"regexp"
 	"strings"
+	"time"
 	"fmt"
 	"context"
+	"database/sql"
+	"github.com/google/uuid"
 	"github.com/kmilanbanda/chirpy/internal/database"
 	"github.com/kmilanbanda/chirpy/internal/auth"
 )
@@ -20,10 +24,33 @@ func getProfaneWords() map[string]struct{} {
 	return words
 }
 
+// chirpWords splits text into the words the content filters match against
+func chirpWords(text string) []string {
+	return strings.Split(text, " ")
+}
+
+// normalizeWord is how the content filters compare words
+func normalizeWord(word string) string {
+	return strings.ToLower(word)
+}
+
+// normalizeWords joins the normalized words of text with single spaces. Muted phrases
+// and the copies of chirp text they are matched against in SQL are both stored this
+// way, so a phrase matches chirps containing those whole words in that order.
+func normalizeWords(text string) string {
+	words := []string{}
+	for _, word := range chirpWords(text) {
+		if word != "" {
+			words = append(words, normalizeWord(word))
+		}
+	}
+	return strings.Join(words, " ")
+}
+
 func censorProfanity(text string, profanities map[string]struct{}) string {
-	words := strings.Split(text, " ")
+	words := chirpWords(text)
 	for i, word := range words {
-		_, exists := profanities[strings.ToLower(word)]
+		_, exists := profanities[normalizeWord(word)]
 		if exists {
 			words[i] = "****"
 		}
@@ -32,13 +59,192 @@ func censorProfanity(text string, profanities map[string]struct{}) string {
 	return strings.Join(words, " ")
 }
 
+// chirpInput is everything a chirp can be posted with. Drafts store the same fields.
+type chirpInput struct {
+	Body 		string		`json:"body"`
+	MediaIDs	[]uuid.UUID	`json:"media_ids"`
+	Poll		*pollRequest	`json:"poll"`
+	PublishAt	*time.Time	`json:"publish_at"`
+	Visibility	string		`json:"visibility"`
+	ContentWarning	string		`json:"content_warning"`
+}
+
+// maxContentWarningLength is in characters. Clients show the warning in place of the
+// collapsed chirp, so it is kept short.
+const maxContentWarningLength = 100
+
+// chirpVisibilities are who can read a chirp. Unlisted chirps can be read by anyone
+// with the link or on the author's profile but are left out of GET /api/chirps.
+// Mentioned chirps are only visible to the users they @mention.
+var chirpVisibilities = map[string]struct{}{
+	"public":	{},
+	"unlisted":	{},
+	"followers":	{},
+	"mentioned":	{},
+}
+
+var mentionPattern = regexp.MustCompile(`(?:^|[^A-Za-z0-9_])@([A-Za-z0-9_]{3,30})\b`)
+
+// mentionedHandles returns the lowercased handles @mentioned in a chirp body
+func mentionedHandles(body string) []string {
+	handles := []string{}
+	seen := map[string]struct{}{}
+	for _, match := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		handle := strings.ToLower(match[1])
+		if _, duplicate := seen[handle]; !duplicate {
+			seen[handle] = struct{}{}
+			handles = append(handles, handle)
+		}
+	}
+	return handles
+}
+
+// saveMentions records which users a chirp mentions. Handles that do not belong to
+// anyone, the author's own handle and users who have blocked the author are ignored.
+func saveMentions(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	handles := mentionedHandles(chirp.Body)
+	if len(handles) == 0 {
+		return nil
+	}
+	getMentionableUsersParams := database.GetMentionableUsersParams{
+		Handles:	handles,
+		AuthorID:	chirp.UserID,
+	}
+	users, err := q.GetMentionableUsers(ctx, getMentionableUsersParams)
+	if err != nil {
+		return err
+	}
+	for _, user := range users {
+		createChirpMentionParams := database.CreateChirpMentionParams{
+			ChirpID:	chirp.ID,
+			UserID:		user.ID,
+		}
+		if err := q.CreateChirpMention(ctx, createChirpMentionParams); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// publishChirp validates the input and creates the chirp with its media and poll in
+// one transaction, so a failure leaves the uploads as they were. It is shared by
+// handlerPostChirp and publishing drafts so both enforce the same rules. On failure it
+// returns the HTTP status to respond with.
+func (cfg *apiConfig) publishChirp(ctx context.Context, userID uuid.UUID, input chirpInput) (database.Chirp, int, error) {
+	if cfg.requireVerifiedEmail {
+		user, err := cfg.db.GetUserByID(ctx, userID)
+		if err != nil {
+			return database.Chirp{}, http.StatusNotFound, fmt.Errorf("Error finding user")
+		}
+		if !user.EmailVerifiedAt.Valid {
+			return database.Chirp{}, http.StatusForbidden, fmt.Errorf("Email address must be verified before posting")
+		}
+	}
+
+	if len(input.Body) > cfg.maxChirpLength {
+		return database.Chirp{}, http.StatusBadRequest, fmt.Errorf("Chirp is too long")
+	}
+
+	input.ContentWarning = strings.TrimSpace(input.ContentWarning)
+	if err := validateProfileField("Content warning", input.ContentWarning, maxContentWarningLength); err != nil {
+		return database.Chirp{}, http.StatusBadRequest, err
+	}
+
+	if input.Visibility == "" {
+		input.Visibility = "public"
+	}
+	if _, ok := chirpVisibilities[input.Visibility]; !ok {
+		return database.Chirp{}, http.StatusBadRequest, fmt.Errorf("Visibility must be \"public\", \"unlisted\", \"followers\" or \"mentioned\"")
+	}
+
+	if err := cfg.checkChirpMedia(ctx, userID, input.MediaIDs); err != nil {
+		return database.Chirp{}, http.StatusBadRequest, err
+	}
+
+	// A scheduled chirp's poll runs from when the chirp is published
+	publishTime := time.Now()
+	if input.PublishAt != nil {
+		if err := validatePublishAt(*input.PublishAt); err != nil {
+			return database.Chirp{}, http.StatusBadRequest, err
+		}
+		publishTime = *input.PublishAt
+	}
+
+	if input.Poll != nil {
+		if len(input.MediaIDs) > 0 {
+			return database.Chirp{}, http.StatusBadRequest, fmt.Errorf("A chirp cannot have both images and a poll")
+		}
+		if err := input.Poll.validate(publishTime); err != nil {
+			return database.Chirp{}, http.StatusBadRequest, err
+		}
+	}
+
+	var chirp database.Chirp
+	status := http.StatusInternalServerError
+	err := cfg.inTx(ctx, func(q *database.Queries) error {
+		var err error
+		if input.PublishAt != nil {
+			createScheduledChirpParams := database.CreateScheduledChirpParams{
+				Body:		input.Body,
+				UserID:		userID,
+				Visibility:	input.Visibility,
+				ContentWarning:	input.ContentWarning,
+				BodyWords:	normalizeWords(input.Body),
+				ContentWarningWords:	normalizeWords(input.ContentWarning),
+				// publish_at has no time zone, so it is stored in UTC like the other timestamps
+				PublishAt:	sql.NullTime{Time: input.PublishAt.UTC(), Valid: true},
+			}
+			chirp, err = q.CreateScheduledChirp(ctx, createScheduledChirpParams)
+		} else {
+			createChirpParams := database.CreateChirpParams{
+				Body:		input.Body,
+				UserID:		userID,
+				Visibility:	input.Visibility,
+				ContentWarning:	input.ContentWarning,
+				BodyWords:	normalizeWords(input.Body),
+				ContentWarningWords:	normalizeWords(input.ContentWarning),
+			}
+			chirp, err = q.CreateChirp(ctx, createChirpParams)
+		}
+		if err != nil {
+			return fmt.Errorf("Error creating chirp: %v", err)
+		}
+
+		if err := saveMentions(ctx, q, chirp); err != nil {
+			return fmt.Errorf("Error saving mentions")
+		}
+
+		// Another chirp may have taken an upload since checkChirpMedia looked at it
+		if err := attachChirpMedia(ctx, q, userID, chirp.ID, input.MediaIDs); err != nil {
+			status = http.StatusConflict
+			return err
+		}
+
+		if input.Poll != nil {
+			if err := createPoll(ctx, q, chirp.ID, *input.Poll); err != nil {
+				return fmt.Errorf("Error creating poll")
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return database.Chirp{}, status, err
+	}
+
+	if chirp.PublishedAt.Valid {
+		go cfg.fetchLinkPreview(chirp.Body)
+	}
+
+	return chirp, http.StatusCreated, nil
+}
+
 func (cfg *apiConfig) handlerPostChirp(w http.ResponseWriter, req *http.Request) {
 	token, err := auth.GetBearerToken(req.Header)
 	if err != nil {
 		handleErrorResponse(w, http.StatusUnauthorized, "Failed to read header")
 		return
 	}
-	validatedUserID, err := auth.ValidateJWT(token, cfg.secret)
+	validatedUserID, err := auth.ValidateScopedJWT(token, cfg.secret, "chirps:write")
 	if err != nil {
 		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
 		return
@@ -46,33 +252,25 @@ func (cfg *apiConfig) handlerPostChirp(w http.ResponseWriter, req *http.Request)
 
 	w.Header().Set("Content-Type", "application/json")
 
-	type request struct {
-		Body 	string `json:"body"`
-	}
-
-	var reqBody request
+	var reqBody chirpInput
 	if err := json.NewDecoder(req.Body).Decode(&reqBody); err != nil {
 		handleErrorResponse(w, http.StatusInternalServerError, "Error decoding request parameters")
 		return
 	}
 
-	if len(reqBody.Body) > cfg.maxChirpLength {
-		handleErrorResponse(w, http.StatusBadRequest, "Chirp is too long")	
+	chirp, status, err := cfg.publishChirp(context.Background(), validatedUserID, reqBody)
+	if err != nil {
+		handleErrorResponse(w, status, err.Error())
 		return
 	}
 
-	createChirpParams := database.CreateChirpParams{
-		Body:	reqBody.Body,
-		UserID:	validatedUserID,
-	}
-
-	chirp, err := cfg.db.CreateChirp(context.Background(), createChirpParams)
+	resp, err := cfg.chirpResponse(context.Background(), uuid.NullUUID{UUID: validatedUserID, Valid: true}, chirp)
 	if err != nil {
-		handleErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("Error creating chirp: %v", err))
+		handleErrorResponse(w, http.StatusInternalServerError, "Error getting chirp media")
 		return
 	}
 	
 	w.WriteHeader(http.StatusCreated)
-	dat, _ := json.Marshal(chirp)
+	dat, _ := json.Marshal(resp)
 	w.Write(dat)
 }
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNormalizeWords(t *testing.T) {
+	tests := []struct {
+		text	string
+		want	string
+	}{
+		{"", ""},
+		{"   ", ""},
+		{"Kerfuffle", "kerfuffle"},
+		{"  Big   NEWS today ", "big news today"},
+		{"Spoilers: Ending", "spoilers: ending"},
+	}
+	for _, test := range tests {
+		if got := normalizeWords(test.text); got != test.want {
+			t.Errorf("normalizeWords(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
+
+func TestMentionedHandles(t *testing.T) {
+	tests := []struct {
+		body	string
+		want	[]string
+	}{
+		{"no mentions here", []string{}},
+		{"@alice hi", []string{"alice"}},
+		{"hi @Alice and @bob_2, and @ALICE again", []string{"alice", "bob_2"}},
+		{"mail me at someone@example.com", []string{}},
+		{"too short @ab", []string{}},
+		{"(@carol)", []string{"carol"}},
+	}
+	for _, test := range tests {
+		if got := mentionedHandles(test.body); !slices.Equal(got, test.want) {
+			t.Errorf("mentionedHandles(%q) = %q, want %q", test.body, got, test.want)
+		}
+	}
+}
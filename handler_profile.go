@@ -0,0 +1,248 @@
+package main
+
+import (
+	"fmt"
+	"time"
+	"regexp"
+	"context"
+	"strings"
+	"net/url"
+	"net/http"
+	"unicode/utf8"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/kmilanbanda/chirpy/internal/auth"
+	"github.com/kmilanbanda/chirpy/internal/database"
+)
+
+const (
+	maxDisplayNameLength	= 50
+	maxBioLength		= 160
+	maxLocationLength	= 30
+	maxWebsiteLength	= 100
+)
+
+var handlePattern = regexp.MustCompile(`^[A-Za-z0-9_]{3,30}$`)
+
+// reservedHandles would be confusing next to the /api/users/... routes
+var reservedHandles = map[string]struct{}{
+	"me":		{},
+	"verify":	{},
+	"admin":	{},
+	"chirpy":	{},
+}
+
+func validateHandle(handle string) error {
+	if !handlePattern.MatchString(handle) {
+		return fmt.Errorf("Handle must be 3 to 30 letters, numbers or underscores")
+	}
+	if _, reserved := reservedHandles[strings.ToLower(handle)]; reserved {
+		return fmt.Errorf("Handle %q is reserved", handle)
+	}
+	return nil
+}
+
+func validateWebsite(website string) error {
+	if website == "" {
+		return nil
+	}
+	parsed, err := url.Parse(website)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("Website must be an http or https URL")
+	}
+	return nil
+}
+
+func validateProfileField(name, value string, maxLength int) error {
+	if utf8.RuneCountInString(value) > maxLength {
+		return fmt.Errorf("%s must be at most %d characters", name, maxLength)
+	}
+	return nil
+}
+
+type publicProfile struct {
+	ID		uuid.UUID	`json:"id"`
+	Handle		string		`json:"handle"`
+	DisplayName	string		`json:"display_name"`
+	Bio		string		`json:"bio"`
+	Location	string		`json:"location"`
+	Website		string		`json:"website"`
+	AvatarURL	string		`json:"avatar_url"`
+	HeaderURL	string		`json:"header_url"`
+	CreatedAt	time.Time	`json:"created_at"`
+	IsChirpyRed	bool		`json:"is_chirpy_red"`
+	Protected	bool		`json:"protected"`
+	FollowerCount	int64		`json:"follower_count"`
+	FollowingCount	int64		`json:"following_count"`
+	ChirpCount	int64		`json:"chirp_count"`
+}
+
+// getUserByHandleOrID looks users up by ID or case-insensitively by handle. Accounts
+// waiting to be deleted are treated as gone.
+func (cfg *apiConfig) getUserByHandleOrID(ctx context.Context, handleOrID string) (database.User, error) {
+	var user database.User
+	var err error
+	if id, parseErr := uuid.Parse(handleOrID); parseErr == nil {
+		user, err = cfg.db.GetUserByID(ctx, id)
+	} else {
+		user, err = cfg.db.GetUserByHandle(ctx, strings.TrimPrefix(handleOrID, "@"))
+	}
+	if err != nil {
+		return database.User{}, err
+	}
+	if user.DeletionScheduledFor.Valid {
+		return database.User{}, fmt.Errorf("user is scheduled for deletion")
+	}
+	return user, nil
+}
+
+// handlerGetProfile returns the public part of a user's profile. It never includes the email address.
+// The chirp count only counts chirps the reader can see, so it does not give away the
+// activity of protected accounts.
+func (cfg *apiConfig) handlerGetProfile(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	viewerID, err := cfg.optionalViewer(req)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	user, err := cfg.getUserByHandleOrID(context.Background(), req.PathValue("handleOrID"))
+	if err != nil {
+		handleErrorResponse(w, http.StatusNotFound, "Error finding user")
+		return
+	}
+
+	getUserStatsParams := database.GetUserStatsParams{
+		UserID:		user.ID,
+		ViewerID:	viewerID.UUID,
+	}
+	stats, err := cfg.db.GetUserStats(context.Background(), getUserStatsParams)
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error getting profile")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	resp := publicProfile{
+		ID:		user.ID,
+		Handle:		user.Handle,
+		DisplayName:	user.DisplayName,
+		Bio:		user.Bio,
+		Location:	user.Location,
+		Website:	user.Website,
+		AvatarURL:	cfg.blobURL(user.AvatarKey),
+		HeaderURL:	cfg.blobURL(user.HeaderKey),
+		CreatedAt:	user.CreatedAt,
+		IsChirpyRed:	user.IsChirpyRed,
+		Protected:	user.Protected,
+		FollowerCount:	stats.FollowerCount,
+		FollowingCount:	stats.FollowingCount,
+		ChirpCount:	stats.ChirpCount,
+	}
+	dat, _ := json.Marshal(resp)
+	w.Write(dat)
+}
+
+func (cfg *apiConfig) handlerFollowUser(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	followee, err := cfg.getUserByHandleOrID(context.Background(), req.PathValue("handleOrID"))
+	if err != nil {
+		handleErrorResponse(w, http.StatusNotFound, "Error finding user")
+		return
+	}
+	if followee.ID == userID {
+		handleErrorResponse(w, http.StatusBadRequest, "You cannot follow yourself")
+		return
+	}
+
+	isBlockedBetweenParams := database.IsBlockedBetweenParams{
+		UserID:		userID,
+		OtherID:	followee.ID,
+	}
+	blocked, err := cfg.db.IsBlockedBetween(context.Background(), isBlockedBetweenParams)
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error following user")
+		return
+	}
+	if blocked {
+		handleErrorResponse(w, http.StatusForbidden, "You cannot follow this user")
+		return
+	}
+
+	// Following a protected account only requests the follow until the account approves it
+	createFollowParams := database.CreateFollowParams{
+		FollowerID:	userID,
+		FolloweeID:	followee.ID,
+		Status:		"approved",
+	}
+	if followee.Protected {
+		createFollowParams.Status = "pending"
+	}
+	if err := cfg.db.CreateFollow(context.Background(), createFollowParams); err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error following user")
+		return
+	}
+
+	getFollowParams := database.GetFollowParams{
+		FollowerID:	userID,
+		FolloweeID:	followee.ID,
+	}
+	follow, err := cfg.db.GetFollow(context.Background(), getFollowParams)
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error following user")
+		return
+	}
+	if follow.Status == "pending" {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUnfollowUser(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	followee, err := cfg.getUserByHandleOrID(context.Background(), req.PathValue("handleOrID"))
+	if err != nil {
+		handleErrorResponse(w, http.StatusNotFound, "Error finding user")
+		return
+	}
+
+	deleteFollowParams := database.DeleteFollowParams{
+		FollowerID:	userID,
+		FolloweeID:	followee.ID,
+	}
+	if err := cfg.db.DeleteFollow(context.Background(), deleteFollowParams); err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error unfollowing user")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -18,6 +18,10 @@ func (cfg *apiConfig) handlerRefresh(w http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		handleErrorResponse(w, http.StatusUnauthorized, "Unable to find refresh token")
 		return
+	} else if refreshToken.ClientID.Valid {
+		// Tokens issued to OAuth clients are refreshed through /oauth/token with their scope
+		handleErrorResponse(w, http.StatusUnauthorized, "Refresh token belongs to an OAuth client")
+		return
 	} else if refreshToken.RevokedAt.Valid {
 		handleErrorResponse(w, http.StatusUnauthorized, "Refresh token revoked")
 		return
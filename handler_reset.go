@@ -20,6 +20,11 @@ func (cfg *apiConfig) handlerReset(w http.ResponseWriter, r *http.Request) {
 		log.Fatalf("Failed to reset user database: %v", err)
 	}
 
+	err = cfg.db.ResetLoginFailures(context.Background())
+	if err != nil {
+		log.Fatalf("Failed to reset login failures: %v", err)
+	}
+
 	w.WriteHeader(http.StatusOK)
 	_, err = w.Write([]byte(""))
 	if err != nil {
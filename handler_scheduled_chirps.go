@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+	"context"
+	"net/http"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/kmilanbanda/chirpy/internal/auth"
+)
+
+const (
+	maxScheduleAhead	= time.Hour * 24 * 365
+	chirpSchedulerInterval	= time.Second * 30
+)
+
+func validatePublishAt(publishAt time.Time) error {
+	if !publishAt.After(time.Now()) {
+		return fmt.Errorf("publish_at must be in the future")
+	}
+	if publishAt.After(time.Now().Add(maxScheduleAhead)) {
+		return fmt.Errorf("Chirps can be scheduled at most %v ahead", maxScheduleAhead)
+	}
+	return nil
+}
+
+// handlerGetScheduledChirps lists the user's chirps that are not published yet, soonest
+// first. Deleting one with DELETE /api/chirps/{chirpID} cancels it.
+func (cfg *apiConfig) handlerGetScheduledChirps(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateScopedJWT(token, cfg.secret, "chirps:read")
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	chirps, err := cfg.db.GetScheduledChirpsByUser(context.Background(), userID)
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error getting scheduled chirps")
+		return
+	}
+
+	resp, err := cfg.chirpResponses(context.Background(), uuid.NullUUID{UUID: userID, Valid: true}, chirps)
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error getting chirp media")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	dat, _ := json.Marshal(resp)
+	w.Write(dat)
+}
+
+// publishScheduledChirps publishes chirps whose publish_at has passed until ctx is
+// done. The schedule lives in the database, so chirps that came due while the server
+// was down go out on the first run. Each chirp is claimed with FOR UPDATE SKIP LOCKED
+// and a re-check of published_at, so several servers can run this without publishing
+// anything twice.
+func (cfg *apiConfig) publishScheduledChirps(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			chirps, err := cfg.db.PublishDueChirps(ctx)
+			if err != nil {
+				log.Printf("Error publishing scheduled chirps: %v", err)
+				break
+			}
+			for _, chirp := range chirps {
+				go cfg.fetchLinkPreview(chirp.Body)
+			}
+			// A full batch means there may be more due
+			if len(chirps) < 100 {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"log"
+	"time"
+	"strconv"
+	"context"
+	"net/http"
+	"encoding/json"
+
+	"github.com/kmilanbanda/chirpy/internal/auth"
+	"github.com/kmilanbanda/chirpy/internal/database"
+)
+
+const recoveryCodeCount = 10
+
+func (cfg *apiConfig) handlerEnrollTOTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	user, err := cfg.db.GetUserByID(context.Background(), userID)
+	if err != nil {
+		handleErrorResponse(w, http.StatusNotFound, "Error finding user")
+		return
+	}
+
+	existing, err := cfg.db.GetUserTOTP(context.Background(), userID)
+	if err == nil && existing.ConfirmedAt.Valid {
+		handleErrorResponse(w, http.StatusConflict, "Two-factor authentication is already enabled")
+		return
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error generating TOTP secret")
+		return
+	}
+	upsertUserTOTPParams := database.UpsertUserTOTPParams{
+		UserID:	userID,
+		Secret:	secret,
+	}
+	if _, err := cfg.db.UpsertUserTOTP(context.Background(), upsertUserTOTPParams); err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error saving TOTP secret")
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	resp := struct{
+		Secret		string	`json:"secret"`
+		OTPAuthURI	string	`json:"otpauth_uri"`
+	}{
+		Secret:		secret,
+		OTPAuthURI:	auth.TOTPURI(secret, user.Email, "Chirpy"),
+	}
+	dat, _ := json.Marshal(resp)
+	w.Write(dat)
+}
+
+// handlerConfirmTOTP turns on two-factor authentication once the user proves their
+// authenticator works, and hands out recovery codes. They are only shown this once.
+func (cfg *apiConfig) handlerConfirmTOTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	type request struct {
+		Code	string	`json:"code"`
+	}
+
+	var reqBody request
+	if err := json.NewDecoder(req.Body).Decode(&reqBody); err != nil {
+		handleErrorResponse(w, http.StatusBadRequest, "Error decoding request parameters")
+		return
+	}
+
+	totp, err := cfg.db.GetUserTOTP(context.Background(), userID)
+	if err != nil {
+		handleErrorResponse(w, http.StatusNotFound, "Two-factor authentication has not been set up")
+		return
+	} else if totp.ConfirmedAt.Valid {
+		handleErrorResponse(w, http.StatusConflict, "Two-factor authentication is already enabled")
+		return
+	}
+
+	step, err := auth.ValidateTOTP(totp.Secret, reqBody.Code, time.Now())
+	if err != nil {
+		handleErrorResponse(w, http.StatusBadRequest, "Invalid code")
+		return
+	}
+
+	confirmUserTOTPParams := database.ConfirmUserTOTPParams{
+		UserID:		userID,
+		LastUsedStep:	step,
+	}
+	if _, err := cfg.db.ConfirmUserTOTP(context.Background(), confirmUserTOTPParams); err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error enabling two-factor authentication")
+		return
+	}
+
+	codes, err := auth.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error generating recovery codes")
+		return
+	}
+	if err := cfg.db.DeleteRecoveryCodes(context.Background(), userID); err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error saving recovery codes")
+		return
+	}
+	for _, code := range codes {
+		createRecoveryCodeParams := database.CreateRecoveryCodeParams{
+			UserID:		userID,
+			CodeHash:	auth.HashRecoveryCode(code),
+		}
+		if err := cfg.db.CreateRecoveryCode(context.Background(), createRecoveryCodeParams); err != nil {
+			handleErrorResponse(w, http.StatusInternalServerError, "Error saving recovery codes")
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	resp := struct{
+		RecoveryCodes	[]string	`json:"recovery_codes"`
+	}{
+		RecoveryCodes:	codes,
+	}
+	dat, _ := json.Marshal(resp)
+	w.Write(dat)
+}
+
+func (cfg *apiConfig) handlerDisableTOTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	type request struct {
+		Password	string	`json:"password"`
+	}
+
+	var reqBody request
+	if err := json.NewDecoder(req.Body).Decode(&reqBody); err != nil {
+		handleErrorResponse(w, http.StatusBadRequest, "Error decoding request parameters")
+		return
+	}
+
+	user, err := cfg.db.GetUserByID(context.Background(), userID)
+	if err != nil {
+		handleErrorResponse(w, http.StatusNotFound, "Error finding user")
+		return
+	}
+	if err := auth.CheckPasswordHash(user.HashedPassword, reqBody.Password); err != nil {
+		handleErrorResponse(w, http.StatusForbidden, "Password is incorrect")
+		return
+	}
+
+	if err := cfg.db.DeleteUserTOTP(context.Background(), userID); err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error disabling two-factor authentication")
+		return
+	}
+	if err := cfg.db.DeleteRecoveryCodes(context.Background(), userID); err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error deleting recovery codes")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerLoginMFA is the second step of a login for users with two-factor
+// authentication. It exchanges the MFA token and a TOTP or recovery code for tokens.
+func (cfg *apiConfig) handlerLoginMFA(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	type request struct {
+		MFAToken	string	`json:"mfa_token"`
+		Code		string	`json:"code"`
+		RecoveryCode	string	`json:"recovery_code"`
+	}
+
+	var reqBody request
+	if err := json.NewDecoder(req.Body).Decode(&reqBody); err != nil {
+		handleErrorResponse(w, http.StatusBadRequest, "Error decoding request parameters")
+		return
+	}
+
+	userID, err := auth.ValidateMFAToken(reqBody.MFAToken, cfg.secret)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid MFA token")
+		return
+	}
+
+	user, err := cfg.db.GetUserByID(context.Background(), userID)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid MFA token")
+		return
+	}
+
+	accountKey := accountLoginKey(user.Email)
+	ipKey := ipLoginKey(req)
+	if lockedUntil := cfg.loginLockedUntil(context.Background(), accountKey, ipKey); !lockedUntil.IsZero() {
+		retryAfter := int(time.Until(lockedUntil).Seconds()) + 1
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		handleErrorResponse(w, http.StatusTooManyRequests, "Too many failed login attempts, try again later")
+		return
+	}
+
+	totp, err := cfg.db.GetUserTOTP(context.Background(), userID)
+	if err != nil || !totp.ConfirmedAt.Valid {
+		handleErrorResponse(w, http.StatusUnauthorized, "Two-factor authentication is not enabled")
+		return
+	}
+
+	verified := false
+	if reqBody.Code != "" {
+		step, err := auth.ValidateTOTP(totp.Secret, reqBody.Code, time.Now())
+		if err == nil {
+			useTOTPStepParams := database.UseTOTPStepParams{
+				UserID:		userID,
+				LastUsedStep:	step,
+			}
+			rows, err := cfg.db.UseTOTPStep(context.Background(), useTOTPStepParams)
+			verified = err == nil && rows == 1
+		}
+	} else if reqBody.RecoveryCode != "" {
+		useRecoveryCodeParams := database.UseRecoveryCodeParams{
+			UserID:		userID,
+			CodeHash:	auth.HashRecoveryCode(reqBody.RecoveryCode),
+		}
+		rows, err := cfg.db.UseRecoveryCode(context.Background(), useRecoveryCodeParams)
+		verified = err == nil && rows == 1
+	}
+
+	if !verified {
+		if err := cfg.recordLoginFailure(context.Background(), accountKey, accountLoginThreshold); err != nil {
+			log.Printf("Error recording failed login: %v", err)
+		}
+		if err := cfg.recordLoginFailure(context.Background(), ipKey, ipLoginThreshold); err != nil {
+			log.Printf("Error recording failed login: %v", err)
+		}
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid code")
+		return
+	}
+
+	if err := cfg.db.ClearLoginFailures(context.Background(), accountKey); err != nil {
+		log.Printf("Error clearing failed logins: %v", err)
+	}
+
+	cfg.writeLoginResponse(w, user)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"crypto/subtle"
+	"encoding/json"
+
+	"github.com/kmilanbanda/chirpy/internal/auth"
+)
+
+// handlerUnlockLogin clears failed login attempts for an account and/or an IP address
+func (cfg *apiConfig) handlerUnlockLogin(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if cfg.adminKey == "" {
+		handleErrorResponse(w, http.StatusForbidden, "Admin API is disabled")
+		return
+	}
+	apiKey, err := auth.GetAPIKey(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting API Key")
+		return
+	} else if subtle.ConstantTimeCompare([]byte(apiKey), []byte(cfg.adminKey)) != 1 {
+		handleErrorResponse(w, http.StatusUnauthorized, "API Key does not match")
+		return
+	}
+
+	type request struct {
+		Email	string	`json:"email"`
+		IP	string	`json:"ip"`
+	}
+
+	var reqBody request
+	if err := json.NewDecoder(req.Body).Decode(&reqBody); err != nil {
+		handleErrorResponse(w, http.StatusBadRequest, "Error decoding request parameters")
+		return
+	}
+	if reqBody.Email == "" && reqBody.IP == "" {
+		handleErrorResponse(w, http.StatusBadRequest, "Provide an email and/or an ip to unlock")
+		return
+	}
+
+	if reqBody.Email != "" {
+		if err := cfg.db.ClearLoginFailures(context.Background(), accountLoginKey(reqBody.Email)); err != nil {
+			handleErrorResponse(w, http.StatusInternalServerError, "Error unlocking account")
+			return
+		}
+	}
+	if reqBody.IP != "" {
+		if err := cfg.db.ClearLoginFailures(context.Background(), "ip:"+reqBody.IP); err != nil {
+			handleErrorResponse(w, http.StatusInternalServerError, "Error unlocking IP address")
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"log"
 	"time"
 	"context"
 	"net/http"
@@ -34,7 +35,23 @@ func (cfg *apiConfig) handlerUpdateUser(w http.ResponseWriter, req *http.Request
 		return
 	}
 
-	newHashedPassword, err := auth.HashPassword(reqBody.Password)
+	if err := validateEmail(reqBody.Email); err != nil {
+		handleErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := cfg.passwordPolicy.Validate(reqBody.Password, reqBody.Email); err != nil {
+		handlePasswordPolicyError(w, err)
+		return
+	}
+
+	currentUser, err := cfg.db.GetUserByID(context.Background(), userID)
+	if err != nil {
+		handleErrorResponse(w, http.StatusNotFound, "Error finding user")
+		return
+	}
+
+	newHashedPassword, err := cfg.hasher.Hash(reqBody.Password)
 	if err != nil {
 		handleErrorResponse(w, http.StatusInternalServerError, "Error hashing password")
 		return
@@ -46,21 +63,34 @@ func (cfg *apiConfig) handlerUpdateUser(w http.ResponseWriter, req *http.Request
 	}
 
 	user, err := cfg.db.UpdateUser(context.Background(), updateUserParams)
-	if err != nil {
+	if isUniqueViolation(err, usersEmailConstraint) {
+		handleErrorResponse(w, http.StatusConflict, "Email address is already in use")
+		return
+	} else if err != nil {
 		handleErrorResponse(w, http.StatusInternalServerError, "Error updating user")
 		return	
 	}
 
+	if user.Email != currentUser.Email {
+		if err := cfg.sendVerificationEmail(context.Background(), user); err != nil {
+			log.Printf("Error sending verification email to user %s: %v", user.ID, err)
+		}
+	}
+
 	resp := struct{
 		ID		uuid.UUID 	`json:"id"`
 		CreatedAt	time.Time	`json:"created_at"`
 		UpdatedAt	time.Time	`json:"updated_at"`
 		Email		string		`json:"email"`
+		IsEmailVerified	bool		`json:"is_email_verified"`
+		Handle		string		`json:"handle"`
 	}{
 		ID:		user.ID,
 		CreatedAt:	user.CreatedAt,
 		UpdatedAt:	user.UpdatedAt,
 		Email:		user.Email,
+		IsEmailVerified:	user.EmailVerifiedAt.Valid,
+		Handle:		user.Handle,
 	}
 	dat, _  := json.Marshal(resp)
 	w.Write(dat)
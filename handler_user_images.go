@@ -0,0 +1,196 @@
+package main
+
+import (
+	"io"
+	"fmt"
+	"log"
+	"bytes"
+	"errors"
+	"context"
+	"net/http"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/kmilanbanda/chirpy/internal/auth"
+	"github.com/kmilanbanda/chirpy/internal/imaging"
+	"github.com/kmilanbanda/chirpy/internal/database"
+)
+
+const maxImageUploadSize = 10 << 20
+
+// profileImage describes one of the images a user can put on their profile
+type profileImage struct {
+	name	string
+	width	int
+	height	int
+	key	func(database.User) sql.NullString
+	update	func(ctx context.Context, db *database.Queries, userID uuid.UUID, key sql.NullString) (database.User, error)
+}
+
+var (
+	avatarImage = profileImage{
+		name:	"avatar",
+		width:	400,
+		height:	400,
+		key:	func(user database.User) sql.NullString { return user.AvatarKey },
+		update:	func(ctx context.Context, db *database.Queries, userID uuid.UUID, key sql.NullString) (database.User, error) {
+			return db.UpdateUserAvatar(ctx, database.UpdateUserAvatarParams{ID: userID, AvatarKey: key})
+		},
+	}
+	headerImage = profileImage{
+		name:	"header",
+		width:	1500,
+		height:	500,
+		key:	func(user database.User) sql.NullString { return user.HeaderKey },
+		update:	func(ctx context.Context, db *database.Queries, userID uuid.UUID, key sql.NullString) (database.User, error) {
+			return db.UpdateUserHeader(ctx, database.UpdateUserHeaderParams{ID: userID, HeaderKey: key})
+		},
+	}
+)
+
+// blobURL returns the public URL of a stored blob, or "" when there is none
+func (cfg *apiConfig) blobURL(key sql.NullString) string {
+	if !key.Valid {
+		return ""
+	}
+	return cfg.blobStore.URL(key.String)
+}
+
+func (cfg *apiConfig) handlerPutAvatar(w http.ResponseWriter, req *http.Request) {
+	cfg.putProfileImage(w, req, avatarImage)
+}
+
+func (cfg *apiConfig) handlerDeleteAvatar(w http.ResponseWriter, req *http.Request) {
+	cfg.deleteProfileImage(w, req, avatarImage)
+}
+
+func (cfg *apiConfig) handlerPutHeader(w http.ResponseWriter, req *http.Request) {
+	cfg.putProfileImage(w, req, headerImage)
+}
+
+func (cfg *apiConfig) handlerDeleteHeader(w http.ResponseWriter, req *http.Request) {
+	cfg.deleteProfileImage(w, req, headerImage)
+}
+
+// putProfileImage reads the "image" field of a multipart upload, crops it to the
+// standard size and stores it re-encoded, which also drops EXIF metadata such as the
+// location a photo was taken at
+func (cfg *apiConfig) putProfileImage(w http.ResponseWriter, req *http.Request, kind profileImage) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	req.Body = http.MaxBytesReader(w, req.Body, maxImageUploadSize)
+	file, _, err := req.FormFile("image")
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		handleErrorResponse(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Image must be smaller than %d MB", maxImageUploadSize>>20))
+		return
+	} else if err != nil {
+		handleErrorResponse(w, http.StatusBadRequest, "Error reading the \"image\" file")
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		handleErrorResponse(w, http.StatusBadRequest, "Error reading image")
+		return
+	}
+
+	img, err := imaging.Decode(data)
+	if errors.Is(err, imaging.ErrUnsupportedFormat) || errors.Is(err, imaging.ErrTooLarge) {
+		handleErrorResponse(w, http.StatusUnsupportedMediaType, err.Error())
+		return
+	} else if err != nil {
+		handleErrorResponse(w, http.StatusBadRequest, "Error decoding image")
+		return
+	}
+
+	var buf bytes.Buffer
+	contentType, err := imaging.Encode(&buf, imaging.Fill(img, kind.width, kind.height))
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error encoding image")
+		return
+	}
+
+	currentUser, err := cfg.db.GetUserByID(context.Background(), userID)
+	if err != nil {
+		handleErrorResponse(w, http.StatusNotFound, "Error finding user")
+		return
+	}
+
+	key := fmt.Sprintf("%ss/%s/%s%s", kind.name, userID, uuid.NewString(), imaging.Extension(contentType))
+	if err := cfg.blobStore.Put(context.Background(), key, buf.Bytes(), contentType); err != nil {
+		log.Printf("Error storing %s for user %s: %v", kind.name, userID, err)
+		handleErrorResponse(w, http.StatusInternalServerError, "Error storing image")
+		return
+	}
+
+	user, err := kind.update(context.Background(), cfg.db, userID, sql.NullString{String: key, Valid: true})
+	if err != nil {
+		cfg.blobStore.Delete(context.Background(), key)
+		handleErrorResponse(w, http.StatusInternalServerError, "Error updating user")
+		return
+	}
+	cfg.deleteBlob(kind.key(currentUser))
+
+	w.WriteHeader(http.StatusOK)
+	resp := struct{
+		URL	string	`json:"url"`
+	}{
+		URL:	cfg.blobURL(kind.key(user)),
+	}
+	dat, _ := json.Marshal(resp)
+	w.Write(dat)
+}
+
+func (cfg *apiConfig) deleteProfileImage(w http.ResponseWriter, req *http.Request, kind profileImage) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	currentUser, err := cfg.db.GetUserByID(context.Background(), userID)
+	if err != nil {
+		handleErrorResponse(w, http.StatusNotFound, "Error finding user")
+		return
+	}
+
+	if _, err := kind.update(context.Background(), cfg.db, userID, sql.NullString{}); err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error updating user")
+		return
+	}
+	cfg.deleteBlob(kind.key(currentUser))
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// deleteBlob removes a blob that is no longer referenced. Failures only leave an
+// orphaned file behind, so they are logged rather than returned.
+func (cfg *apiConfig) deleteBlob(key sql.NullString) {
+	if !key.Valid {
+		return
+	}
+	if err := cfg.blobStore.Delete(context.Background(), key.String); err != nil {
+		log.Printf("Error deleting blob %s: %v", key.String, err)
+	}
+}
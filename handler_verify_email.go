@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"time"
+	"context"
+	"net/url"
+	"net/http"
+	"net/mail"
+	"encoding/json"
+
+	"github.com/kmilanbanda/chirpy/internal/auth"
+	"github.com/kmilanbanda/chirpy/internal/mailer"
+	"github.com/kmilanbanda/chirpy/internal/database"
+)
+
+const emailVerificationDuration = time.Hour * 24
+
+func validateEmail(email string) error {
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return fmt.Errorf("Invalid email address")
+	}
+
+	return nil
+}
+
+func (cfg *apiConfig) sendVerificationEmail(ctx context.Context, user database.User) error {
+	token, err := auth.MakeRefreshToken()
+	if err != nil {
+		return fmt.Errorf("Error making verification token: %w", err)
+	}
+
+	createEmailVerificationParams := database.CreateEmailVerificationParams{
+		Token:		token,
+		UserID:		user.ID,
+		Email:		user.Email,
+		ExpiresAt:	time.Now().Add(emailVerificationDuration),
+	}
+	if _, err := cfg.db.CreateEmailVerification(ctx, createEmailVerificationParams); err != nil {
+		return fmt.Errorf("Error saving verification token: %w", err)
+	}
+
+	link := fmt.Sprintf("%s/api/users/verify?token=%s", cfg.baseURL, url.QueryEscape(token))
+	msg := mailer.Message{
+		To:		user.Email,
+		Subject:	"Verify your Chirpy email address",
+		Body:		fmt.Sprintf("Confirm your email address by opening the link below:\n\n%s\n\nThe link expires in 24 hours.", link),
+	}
+
+	return cfg.mailer.Send(ctx, msg)
+}
+
+func (cfg *apiConfig) handlerVerifyEmail(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	token := req.URL.Query().Get("token")
+	if token == "" {
+		handleErrorResponse(w, http.StatusBadRequest, "Missing verification token")
+		return
+	}
+
+	verification, err := cfg.db.GetEmailVerification(context.Background(), token)
+	if err != nil {
+		handleErrorResponse(w, http.StatusNotFound, "Unable to find verification token")
+		return
+	} else if verification.UsedAt.Valid {
+		handleErrorResponse(w, http.StatusBadRequest, "Verification token already used")
+		return
+	} else if time.Now().After(verification.ExpiresAt) {
+		handleErrorResponse(w, http.StatusBadRequest, "Verification token expired")
+		return
+	}
+
+	verifyUserEmailParams := database.VerifyUserEmailParams{
+		ID:	verification.UserID,
+		Email:	verification.Email,
+	}
+	user, err := cfg.db.VerifyUserEmail(context.Background(), verifyUserEmailParams)
+	if err != nil {
+		handleErrorResponse(w, http.StatusBadRequest, "Email address has changed since the link was sent")
+		return
+	}
+
+	err = cfg.db.UseEmailVerification(context.Background(), token)
+	if err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error updating verification token")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	resp := struct{
+		Email		string	`json:"email"`
+		IsEmailVerified	bool	`json:"is_email_verified"`
+	}{
+		Email:			user.Email,
+		IsEmailVerified:	user.EmailVerifiedAt.Valid,
+	}
+	dat, _ := json.Marshal(resp)
+	w.Write(dat)
+}
+
+func (cfg *apiConfig) handlerResendVerification(w http.ResponseWriter, req *http.Request) {
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Error getting bearer token")
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.secret)
+	if err != nil {
+		handleErrorResponse(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	user, err := cfg.db.GetUserByID(context.Background(), userID)
+	if err != nil {
+		handleErrorResponse(w, http.StatusNotFound, "Error finding user")
+		return
+	}
+	if user.EmailVerifiedAt.Valid {
+		handleErrorResponse(w, http.StatusConflict, "Email address is already verified")
+		return
+	}
+
+	if err := cfg.sendVerificationEmail(context.Background(), user); err != nil {
+		handleErrorResponse(w, http.StatusInternalServerError, "Error sending verification email")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
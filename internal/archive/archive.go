@@ -0,0 +1,161 @@
+package archive
+
+import (
+	"io"
+	"fmt"
+	"time"
+	"archive/zip"
+	"html/template"
+	"encoding/json"
+
+	"github.com/google/uuid"
+)
+
+// FormatVersion is written to manifest.json so future importers can tell old archives apart.
+// Version 2 added the visibility of each chirp.
+const FormatVersion = 2
+
+type Manifest struct {
+	FormatVersion	int		`json:"format_version"`
+	ExportedAt	time.Time	`json:"exported_at"`
+	UserID		uuid.UUID	`json:"user_id"`
+}
+
+type Profile struct {
+	ID			uuid.UUID	`json:"id"`
+	CreatedAt		time.Time	`json:"created_at"`
+	UpdatedAt		time.Time	`json:"updated_at"`
+	Email			string		`json:"email"`
+	Handle			string		`json:"handle"`
+	DisplayName		string		`json:"display_name"`
+	Bio			string		`json:"bio"`
+	Location		string		`json:"location"`
+	Website			string		`json:"website"`
+	IsChirpyRed		bool		`json:"is_chirpy_red"`
+	IsEmailVerified		bool		`json:"is_email_verified"`
+	TwoFactorEnabled	bool		`json:"two_factor_enabled"`
+	Identities		[]Identity	`json:"identities"`
+}
+
+// Identity is an external account linked through OpenID Connect
+type Identity struct {
+	Provider	string		`json:"provider"`
+	Email		string		`json:"email"`
+	LinkedAt	time.Time	`json:"linked_at"`
+}
+
+type Chirp struct {
+	ID		uuid.UUID	`json:"id"`
+	CreatedAt	time.Time	`json:"created_at"`
+	UpdatedAt	time.Time	`json:"updated_at"`
+	Body		string		`json:"body"`
+	Visibility	string		`json:"visibility"`
+	ContentWarning	string		`json:"content_warning,omitempty"`
+	// PublishAt is set on scheduled chirps that had not been published yet
+	PublishAt	*time.Time	`json:"publish_at,omitempty"`
+}
+
+// Session is one refresh token, without the token itself
+type Session struct {
+	CreatedAt	time.Time	`json:"created_at"`
+	ExpiresAt	time.Time	`json:"expires_at"`
+	RevokedAt	*time.Time	`json:"revoked_at"`
+	ClientID	*uuid.UUID	`json:"client_id,omitempty"`
+	Scope		string		`json:"scope,omitempty"`
+}
+
+// Follow is one side of a follow relationship, the other side being the exported user
+type Follow struct {
+	UserID		uuid.UUID	`json:"user_id"`
+	Handle		string		`json:"handle"`
+	Since		time.Time	`json:"since"`
+}
+
+type Export struct {
+	Manifest	Manifest
+	Profile		Profile
+	Chirps		[]Chirp
+	Sessions	[]Session
+	Following	[]Follow
+	Followers	[]Follow
+}
+
+var chirpsPage = template.Must(template.New("chirps").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Chirps by {{.Profile.Email}}</title></head>
+<body>
+	<h1>Chirps by {{.Profile.Email}}</h1>
+	<p>Exported {{.Manifest.ExportedAt.Format "2006-01-02 15:04 MST"}}</p>
+	{{range .Chirps}}<article>
+		<p>{{.Body}}</p>
+		<time datetime="{{.CreatedAt.Format "2006-01-02T15:04:05Z07:00"}}">{{.CreatedAt.Format "2006-01-02 15:04"}}</time>
+	</article>
+	{{else}}<p>No chirps yet.</p>
+	{{end}}
+</body>
+</html>
+`))
+
+// Write writes the export to w as a zip archive with one JSON file per section and a
+// chirps.html page that can be opened in a browser
+func Write(w io.Writer, export Export) error {
+	if export.Manifest.FormatVersion == 0 {
+		export.Manifest.FormatVersion = FormatVersion
+	}
+	if export.Chirps == nil {
+		export.Chirps = []Chirp{}
+	}
+	if export.Sessions == nil {
+		export.Sessions = []Session{}
+	}
+	if export.Following == nil {
+		export.Following = []Follow{}
+	}
+	if export.Followers == nil {
+		export.Followers = []Follow{}
+	}
+	if export.Profile.Identities == nil {
+		export.Profile.Identities = []Identity{}
+	}
+
+	zw := zip.NewWriter(w)
+	files := []struct{
+		name	string
+		value	any
+	}{
+		{"manifest.json", export.Manifest},
+		{"profile.json", export.Profile},
+		{"chirps.json", export.Chirps},
+		{"sessions.json", export.Sessions},
+		{"following.json", export.Following},
+		{"followers.json", export.Followers},
+	}
+	for _, file := range files {
+		if err := writeJSON(zw, file.name, file.value, export.Manifest.ExportedAt); err != nil {
+			return err
+		}
+	}
+
+	page, err := zw.CreateHeader(&zip.FileHeader{Name: "chirps.html", Method: zip.Deflate, Modified: export.Manifest.ExportedAt})
+	if err != nil {
+		return fmt.Errorf("Error adding chirps.html: %w", err)
+	}
+	if err := chirpsPage.Execute(page, export); err != nil {
+		return fmt.Errorf("Error rendering chirps.html: %w", err)
+	}
+
+	return zw.Close()
+}
+
+func writeJSON(zw *zip.Writer, name string, value any, modified time.Time) error {
+	f, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+	if err != nil {
+		return fmt.Errorf("Error adding %s: %w", name, err)
+	}
+	encoder := json.NewEncoder(f)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		return fmt.Errorf("Error writing %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package archive
+
+import (
+	"io"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"archive/zip"
+	"encoding/json"
+
+	"github.com/google/uuid"
+)
+
+func TestWrite(t *testing.T) {
+	userID := uuid.New()
+	export := Export{
+		Manifest:	Manifest{ExportedAt: time.Now().UTC(), UserID: userID},
+		Profile:	Profile{ID: userID, Email: "user@example.com"},
+		Chirps:		[]Chirp{
+			{ID: uuid.New(), CreatedAt: time.Now().UTC(), Body: "hello"},
+			{ID: uuid.New(), CreatedAt: time.Now().UTC(), Body: "<script>alert(1)</script>"},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, export); err != nil {
+		t.Fatalf("Error writing archive: %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Error reading archive: %v", err)
+	}
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("Error opening %s: %v", f.Name, err)
+		}
+		dat, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(dat)
+	}
+
+	for _, name := range []string{"manifest.json", "profile.json", "chirps.json", "sessions.json", "following.json", "followers.json", "chirps.html"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("Expected %s in the archive", name)
+		}
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal([]byte(files["manifest.json"]), &manifest); err != nil || manifest.FormatVersion != FormatVersion {
+		t.Errorf("Unexpected manifest %+v: %v", manifest, err)
+	}
+
+	var chirps []Chirp
+	if err := json.Unmarshal([]byte(files["chirps.json"]), &chirps); err != nil || len(chirps) != 2 {
+		t.Errorf("Expected 2 chirps, got %d: %v", len(chirps), err)
+	}
+
+	if strings.Contains(files["chirps.html"], "<script>") {
+		t.Errorf("Expected chirp bodies to be escaped in chirps.html")
+	}
+	if strings.TrimSpace(files["sessions.json"]) != "[]" {
+		t.Errorf("Expected empty sessions to be written as [], got %q", files["sessions.json"])
+	}
+}
+
+func TestReadChirpyExport(t *testing.T) {
+	older := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+	var buf bytes.Buffer
+	err := Write(&buf, Export{
+		Manifest:	Manifest{ExportedAt: time.Now().UTC()},
+		Chirps:		[]Chirp{
+			{ID: uuid.New(), CreatedAt: newer, Body: "second", Visibility: "public", ContentWarning: "Spoilers"},
+			{ID: uuid.New(), CreatedAt: older, Body: "first", Visibility: "followers"},
+			{ID: uuid.New(), CreatedAt: older, Body: "scheduled", PublishAt: &newer},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Error writing archive: %v", err)
+	}
+
+	source, chirps, err := Read(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Error reading archive: %v", err)
+	}
+	if source != SourceChirpy {
+		t.Errorf("Expected source %q, got %q", SourceChirpy, source)
+	}
+	if len(chirps) != 2 || chirps[0].Body != "first" || !chirps[0].CreatedAt.Equal(older) {
+		t.Errorf("Expected published chirps oldest first with original timestamps, got %+v", chirps)
+	}
+	if chirps[0].Visibility != "followers" || chirps[1].Visibility != "public" {
+		t.Errorf("Expected visibility to be kept, got %+v", chirps)
+	}
+	if chirps[0].ContentWarning != "" || chirps[1].ContentWarning != "Spoilers" {
+		t.Errorf("Expected content warnings to be kept, got %+v", chirps)
+	}
+}
+
+func TestImportedVisibility(t *testing.T) {
+	tests := []struct {
+		formatVersion	int
+		visibility	string
+		want		string
+	}{
+		{1, "", "public"},
+		{2, "public", "public"},
+		{2, "unlisted", "unlisted"},
+		{2, "followers", "followers"},
+		{2, "mentioned", "mentioned"},
+		{2, "", "followers"},
+		{2, "circle", "followers"},
+	}
+	for _, test := range tests {
+		if got := importedVisibility(test.formatVersion, test.visibility); got != test.want {
+			t.Errorf("importedVisibility(%d, %q) = %q, want %q", test.formatVersion, test.visibility, got, test.want)
+		}
+	}
+}
+
+const tweetsJS = `window.YTD.tweets.part0 = [
+	{"tweet": {"id_str": "2", "full_text": "RT @someone: not mine", "created_at": "Thu Oct 11 20:19:24 +0000 2018", "retweeted": false}},
+	{"tweet": {"id_str": "1", "full_text": "fish &amp; chips", "created_at": "Wed Oct 10 20:19:24 +0000 2018", "retweeted": false}}
+]`
+
+func TestReadTwitterArchive(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	f, _ := zw.Create("data/tweets.js")
+	f.Write([]byte(tweetsJS))
+	zw.Close()
+
+	for name, data := range map[string][]byte{"zip": buf.Bytes(), "tweets.js": []byte(tweetsJS)} {
+		source, chirps, err := Read(data)
+		if err != nil {
+			t.Fatalf("%s: Error reading archive: %v", name, err)
+		}
+		if source != SourceTwitter {
+			t.Errorf("%s: Expected source %q, got %q", name, SourceTwitter, source)
+		}
+		if len(chirps) != 2 {
+			t.Fatalf("%s: Expected 2 chirps, got %d", name, len(chirps))
+		}
+		if chirps[0].Body != "fish & chips" || chirps[0].SourceID != "1" {
+			t.Errorf("%s: Expected the oldest tweet unescaped first, got %+v", name, chirps[0])
+		}
+		want := time.Date(2018, 10, 10, 20, 19, 24, 0, time.UTC)
+		if !chirps[0].CreatedAt.Equal(want) {
+			t.Errorf("%s: Expected created at %v, got %v", name, want, chirps[0].CreatedAt)
+		}
+		if chirps[0].IsRetweet || !chirps[1].IsRetweet {
+			t.Errorf("%s: Expected only the second tweet to be a retweet", name)
+		}
+	}
+}
+
+func TestReadUnknownFormat(t *testing.T) {
+	if _, _, err := Read([]byte("not an archive")); err != ErrUnknownFormat {
+		t.Errorf("Expected ErrUnknownFormat, got %v", err)
+	}
+}
@@ -0,0 +1,225 @@
+package archive
+
+import (
+	"io"
+	"fmt"
+	"html"
+	"path"
+	"sort"
+	"time"
+	"bytes"
+	"errors"
+	"strings"
+	"archive/zip"
+	"encoding/json"
+)
+
+const (
+	SourceChirpy	= "chirpy"
+	SourceTwitter	= "twitter"
+)
+
+// maxFileSize caps how much of a single archive entry is decompressed into memory
+const maxFileSize = 64 << 20
+
+var ErrUnknownFormat = errors.New("archive is neither a Chirpy export nor a Twitter archive")
+
+// ImportedChirp is a chirp read from an archive. SourceID is the ID it had in the
+// archive and is only used to point at it in import reports.
+type ImportedChirp struct {
+	SourceID	string
+	CreatedAt	time.Time
+	Body		string
+	Visibility	string
+	ContentWarning	string
+	IsRetweet	bool
+}
+
+// importedVisibility is the visibility a chirp from a Chirpy export is imported with.
+// Exports from before chirps had a visibility only contained public chirps. A value
+// this version does not know is narrowed to "followers" so that an import never makes
+// a chirp more visible than it was.
+func importedVisibility(formatVersion int, visibility string) string {
+	if formatVersion < 2 {
+		return "public"
+	}
+	switch visibility {
+	case "public", "unlisted", "followers", "mentioned":
+		return visibility
+	}
+	return "followers"
+}
+
+// Read detects the format of data and returns its chirps oldest first. It accepts a
+// Chirpy export, a Twitter archive zip, or a tweets.js file taken out of one.
+func Read(data []byte) (string, []ImportedChirp, error) {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("window.YTD.")) {
+		chirps, err := readTweetsJS(data)
+		return SourceTwitter, sortChirps(chirps), err
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", nil, ErrUnknownFormat
+	}
+
+	files := map[string]*zip.File{}
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	if files["manifest.json"] != nil && files["chirps.json"] != nil {
+		chirps, err := readChirpyExport(files)
+		return SourceChirpy, sortChirps(chirps), err
+	}
+
+	var chirps []ImportedChirp
+	found := false
+	for _, f := range zr.File {
+		if !isTweetsFile(f.Name) {
+			continue
+		}
+		dat, err := readZipFile(f)
+		if err != nil {
+			return SourceTwitter, nil, err
+		}
+		part, err := readTweetsJS(dat)
+		if err != nil {
+			return SourceTwitter, nil, fmt.Errorf("Error reading %s: %w", f.Name, err)
+		}
+		chirps = append(chirps, part...)
+		found = true
+	}
+	if !found {
+		return "", nil, ErrUnknownFormat
+	}
+
+	return SourceTwitter, sortChirps(chirps), nil
+}
+
+// isTweetsFile reports whether name holds tweets. Archives split them over
+// data/tweets.js, data/tweets-part1.js and so on, and older ones use tweet.js.
+func isTweetsFile(name string) bool {
+	if path.Dir(name) != "data" || path.Ext(name) != ".js" {
+		return false
+	}
+	base := strings.TrimSuffix(path.Base(name), ".js")
+	for _, prefix := range []string{"tweets", "tweet"} {
+		if base == prefix || strings.HasPrefix(base, prefix+"-part") {
+			return true
+		}
+	}
+	return false
+}
+
+func readChirpyExport(files map[string]*zip.File) ([]ImportedChirp, error) {
+	var manifest Manifest
+	if err := readZipJSON(files["manifest.json"], &manifest); err != nil {
+		return nil, err
+	}
+	if manifest.FormatVersion < 1 || manifest.FormatVersion > FormatVersion {
+		return nil, fmt.Errorf("Unsupported export format version %d", manifest.FormatVersion)
+	}
+
+	var exported []Chirp
+	if err := readZipJSON(files["chirps.json"], &exported); err != nil {
+		return nil, err
+	}
+
+	chirps := []ImportedChirp{}
+	for _, chirp := range exported {
+		if chirp.PublishAt != nil {
+			continue
+		}
+		chirps = append(chirps, ImportedChirp{
+			SourceID:	chirp.ID.String(),
+			CreatedAt:	chirp.CreatedAt,
+			Body:		chirp.Body,
+			Visibility:	importedVisibility(manifest.FormatVersion, chirp.Visibility),
+			ContentWarning:	chirp.ContentWarning,
+		})
+	}
+	return chirps, nil
+}
+
+type twitterTweet struct {
+	IDStr		string	`json:"id_str"`
+	FullText	string	`json:"full_text"`
+	CreatedAt	string	`json:"created_at"`
+	Retweeted	bool	`json:"retweeted"`
+}
+
+// twitterEntry matches both the current {"tweet": {...}} entries and older archives
+// where the tweet fields are at the top level
+type twitterEntry struct {
+	Tweet	*twitterTweet	`json:"tweet"`
+	twitterTweet
+}
+
+func readTweetsJS(data []byte) ([]ImportedChirp, error) {
+	// The file is JavaScript assigning a JSON array, e.g. window.YTD.tweets.part0 = [...]
+	start := bytes.IndexByte(data, '[')
+	if start < 0 {
+		return nil, ErrUnknownFormat
+	}
+
+	var entries []twitterEntry
+	if err := json.Unmarshal(data[start:], &entries); err != nil {
+		return nil, fmt.Errorf("Error decoding tweets: %w", err)
+	}
+
+	chirps := []ImportedChirp{}
+	for _, entry := range entries {
+		tweet := entry.twitterTweet
+		if entry.Tweet != nil {
+			tweet = *entry.Tweet
+		}
+
+		createdAt, err := time.Parse(time.RubyDate, tweet.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("Error parsing date of tweet %s: %w", tweet.IDStr, err)
+		}
+		text := html.UnescapeString(tweet.FullText)
+		chirps = append(chirps, ImportedChirp{
+			SourceID:	tweet.IDStr,
+			CreatedAt:	createdAt.UTC(),
+			Body:		text,
+			Visibility:	"public",
+			IsRetweet:	tweet.Retweeted || strings.HasPrefix(text, "RT @"),
+		})
+	}
+	return chirps, nil
+}
+
+func sortChirps(chirps []ImportedChirp) []ImportedChirp {
+	sort.SliceStable(chirps, func(i, j int) bool { return chirps[i].CreatedAt.Before(chirps[j].CreatedAt) })
+	return chirps
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("Error opening %s: %w", f.Name, err)
+	}
+	defer rc.Close()
+
+	dat, err := io.ReadAll(io.LimitReader(rc, maxFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("Error reading %s: %w", f.Name, err)
+	}
+	if len(dat) > maxFileSize {
+		return nil, fmt.Errorf("%s is larger than %d bytes", f.Name, maxFileSize)
+	}
+	return dat, nil
+}
+
+func readZipJSON(f *zip.File, v any) error {
+	dat, err := readZipFile(f)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(dat, v); err != nil {
+		return fmt.Errorf("Error decoding %s: %w", f.Name, err)
+	}
+	return nil
+}
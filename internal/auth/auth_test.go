@@ -1,8 +1,17 @@
 package auth
 
 import (
+	"os"
+	"fmt"
+	"sort"
+	"errors"
+	"slices"
 	"testing"
 	"time"
+	"strings"
+	"crypto/sha1"
+	"encoding/hex"
+	"path/filepath"
 	"github.com/google/uuid"
 	"net/http"
 )
@@ -83,3 +92,246 @@ func TestGetBearerToken(t *testing.T) {
 		t.Errorf("Token strings don't match")
 	}
 }
+
+func TestPasswordPolicy(t *testing.T) {
+	policy := PasswordPolicy{
+		MinLength:	8,
+		MinEntropyBits:	36,
+		DisallowEmail:	true,
+	}
+
+	cases := []struct{
+		password	string
+		email		string
+		valid		bool
+	}{
+		{"", "a@example.com", false},
+		{"short1", "a@example.com", false},
+		{"aaaaaaaaaaaa", "a@example.com", false},
+		{"correct horse battery", "a@example.com", true},
+		{"Longname@example.com", "longname@example.com", false},
+		{"longname", "longname@example.com", false},
+		{"Tr0ub4dor&3", "a@example.com", true},
+	}
+
+	for _, c := range cases {
+		err := policy.Validate(c.password, c.email)
+		if c.valid && err != nil {
+			t.Errorf("Expected %q to be valid: %v", c.password, err)
+		} else if !c.valid {
+			var policyErr *PasswordPolicyError
+			if !errors.As(err, &policyErr) {
+				t.Errorf("Expected %q to fail with a policy error, got %v", c.password, err)
+			}
+		}
+	}
+}
+
+func TestBreachedPasswordFile(t *testing.T) {
+	sum := sha1.Sum([]byte("hunter2hunter2"))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	contents := "0000000000000000000000000000000000000000:3\n" + strings.ToLower(hash) + ":17\n"
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatalf("Error writing breach list: %v", err)
+	}
+
+	list, err := LoadBreachedPasswordFile(path)
+	if err != nil {
+		t.Fatalf("Error loading breach list: %v", err)
+	}
+	defer list.Close()
+
+	breached, err := IsBreachedPassword(list, "hunter2hunter2")
+	if err != nil || !breached {
+		t.Errorf("Expected password to be found in breach list, got %v %v", breached, err)
+	}
+
+	breached, err = IsBreachedPassword(list, "a different passphrase")
+	if err != nil || breached {
+		t.Errorf("Expected password not to be in breach list, got %v %v", breached, err)
+	}
+
+	policy := PasswordPolicy{MinLength: 8, Breached: list}
+	if err := policy.Validate("hunter2hunter2", ""); err == nil {
+		t.Errorf("Expected breached password to be rejected")
+	}
+}
+
+func TestBreachedPasswordFileSearch(t *testing.T) {
+	var hashes []string
+	for i := 0; i < 500; i++ {
+		sum := sha1.Sum([]byte(fmt.Sprintf("password%d", i)))
+		hashes = append(hashes, strings.ToUpper(hex.EncodeToString(sum[:])))
+	}
+	// Lines that share a prefix must all come back from Range
+	hashes = append(hashes, "ABCDE0000000000000000000000000000000000A", "ABCDE0000000000000000000000000000000000B", "ABCDF00000000000000000000000000000000000")
+	sort.Strings(hashes)
+
+	var contents strings.Builder
+	for i, hash := range hashes {
+		fmt.Fprintf(&contents, "%s:%d\r\n", hash, i+1)
+	}
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	if err := os.WriteFile(path, []byte(contents.String()), 0o600); err != nil {
+		t.Fatalf("Error writing breach list: %v", err)
+	}
+
+	list, err := LoadBreachedPasswordFile(path)
+	if err != nil {
+		t.Fatalf("Error loading breach list: %v", err)
+	}
+	defer list.Close()
+
+	for i := 0; i < 500; i++ {
+		password := fmt.Sprintf("password%d", i)
+		if breached, err := IsBreachedPassword(list, password); err != nil || !breached {
+			t.Errorf("Expected %q to be found in breach list, got %v %v", password, breached, err)
+		}
+	}
+
+	tests := []struct {
+		prefix	string
+		want	[]string
+	}{
+		{"abcde", []string{"0000000000000000000000000000000000A", "0000000000000000000000000000000000B"}},
+		{"ABCDF", []string{"00000000000000000000000000000000000"}},
+		{hashes[0][:5], []string{hashes[0][5:]}},
+		{hashes[len(hashes)-1][:5], []string{hashes[len(hashes)-1][5:]}},
+		{"00000", []string{}},
+		{"FFFFF", []string{}},
+	}
+	for _, test := range tests {
+		suffixes, err := list.Range(test.prefix)
+		if err != nil || !slices.Equal(suffixes, test.want) {
+			t.Errorf("Range(%q) = %v %v, want %v", test.prefix, suffixes, err, test.want)
+		}
+	}
+}
+
+func TestBreachedPasswordFileRejectsInvalidHashes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	if err := os.WriteFile(path, []byte("not a hash:3\n"), 0o600); err != nil {
+		t.Fatalf("Error writing breach list: %v", err)
+	}
+	if _, err := LoadBreachedPasswordFile(path); err == nil {
+		t.Errorf("Expected a breach list with invalid hashes to be rejected")
+	}
+}
+
+func TestTOTPCode(t *testing.T) {
+	// RFC 6238 test secret "12345678901234567890"
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+	code, err := TOTPCode(secret, TOTPStep(time.Unix(59, 0)))
+	if err != nil {
+		t.Errorf("Error making TOTP code: %v", err)
+		return
+	}
+	if code != "287082" {
+		t.Errorf("Expected code 287082, got %s", code)
+	}
+
+	now := time.Unix(1111111109, 0)
+	previous, _ := TOTPCode(secret, TOTPStep(now)-1)
+	if _, err := ValidateTOTP(secret, previous, now); err != nil {
+		t.Errorf("Expected code from the previous step to be accepted: %v", err)
+	}
+
+	stale, _ := TOTPCode(secret, TOTPStep(now)-5)
+	if _, err := ValidateTOTP(secret, stale, now); err == nil {
+		t.Errorf("Expected stale code to be rejected")
+	}
+}
+
+func TestMFATokenIsNotAnAccessToken(t *testing.T) {
+	userID := uuid.New()
+	secret := "secret"
+
+	mfaToken, err := MakeMFAToken(userID, secret, time.Minute)
+	if err != nil {
+		t.Errorf("Error making MFA token: %v", err)
+		return
+	}
+
+	if _, err := ValidateJWT(mfaToken, secret); err == nil {
+		t.Errorf("MFA token should not be accepted as an access token")
+	}
+
+	validatedID, err := ValidateMFAToken(mfaToken, secret)
+	if err != nil || validatedID != userID {
+		t.Errorf("Expected MFA token to validate for %v, got %v %v", userID, validatedID, err)
+	}
+}
+
+func TestPasswordHasherUpgrade(t *testing.T) {
+	bcryptHasher := PasswordHasher{Algorithm: AlgorithmBcrypt, BcryptCost: 4}
+	argonHasher := PasswordHasher{Algorithm: AlgorithmArgon2id, Argon2: Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}}
+
+	oldHash, err := bcryptHasher.Hash("p4ssword")
+	if err != nil {
+		t.Errorf("Error hashing password: %v", err)
+		return
+	}
+	if bcryptHasher.NeedsRehash(oldHash) {
+		t.Errorf("Hash made with current settings should not need a rehash")
+	}
+	if !argonHasher.NeedsRehash(oldHash) {
+		t.Errorf("bcrypt hash should need a rehash when argon2id is preferred")
+	}
+
+	newHash, err := argonHasher.Hash("p4ssword")
+	if err != nil {
+		t.Errorf("Error hashing password: %v", err)
+		return
+	}
+	if !strings.HasPrefix(newHash, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Errorf("Unexpected argon2id hash format: %s", newHash)
+	}
+	if argonHasher.NeedsRehash(newHash) {
+		t.Errorf("Hash made with current settings should not need a rehash")
+	}
+
+	for _, hash := range []string{oldHash, newHash} {
+		if err := CheckPasswordHash(hash, "p4ssword"); err != nil {
+			t.Errorf("Expected password to match %s: %v", hash, err)
+		}
+		if err := CheckPasswordHash(hash, "wrong"); err == nil {
+			t.Errorf("Expected wrong password to fail for %s", hash)
+		}
+	}
+
+	stronger := argonHasher
+	stronger.Argon2.Iterations = 2
+	if !stronger.NeedsRehash(newHash) {
+		t.Errorf("Hash should need a rehash when the preferred iterations change")
+	}
+}
+
+func TestScopedTokens(t *testing.T) {
+	userID := uuid.New()
+	secret := "secret"
+
+	token, err := MakeScopedJWT(userID, secret, time.Hour, uuid.NewString(), "chirps:read")
+	if err != nil {
+		t.Errorf("Error making scoped JWT: %v", err)
+		return
+	}
+
+	if _, err := ValidateJWT(token, secret); err == nil {
+		t.Errorf("Client token should not be accepted as a first-party token")
+	}
+	if _, err := ValidateScopedJWT(token, secret, "chirps:write"); err == nil {
+		t.Errorf("Client token should not be accepted for a scope it was not granted")
+	}
+	validatedID, err := ValidateScopedJWT(token, secret, "chirps:read")
+	if err != nil || validatedID != userID {
+		t.Errorf("Expected client token to be valid for its scope, got %v %v", validatedID, err)
+	}
+
+	firstParty, _ := MakeJWT(userID, secret, time.Hour)
+	if _, err := ValidateScopedJWT(firstParty, secret, "chirps:write"); err != nil {
+		t.Errorf("First-party token should be valid for every scope: %v", err)
+	}
+}
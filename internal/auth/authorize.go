@@ -8,34 +8,50 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 
-	"golang.org/x/crypto/bcrypt"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
-func HashPassword(password string) (string, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		return "", fmt.Errorf("Error hashing password: %w", err)
-	}
-
-	return string(hashedPassword), nil
+// AccessClaims are the claims in an access token. Tokens issued to third-party OAuth
+// clients also carry the client ID and the space separated scopes the user granted.
+type AccessClaims struct {
+	jwt.RegisteredClaims
+	ClientID	string	`json:"client_id,omitempty"`
+	Scope		string	`json:"scope,omitempty"`
 }
 
-func CheckPasswordHash(hash, password string) error {	
-	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+// HasScope reports whether the token allows scope. First-party tokens allow everything.
+func (c AccessClaims) HasScope(scope string) bool {
+	if c.ClientID == "" {
+		return true
+	}
+	for _, s := range strings.Fields(c.Scope) {
+		if s == scope {
+			return true
+		}
+	}
+	return false
 }
 
 func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (string, error) {
+	return MakeScopedJWT(userID, tokenSecret, expiresIn, "", "")
+}
+
+// MakeScopedJWT makes an access token for a third-party OAuth client limited to scope
+func MakeScopedJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration, clientID, scope string) (string, error) {
 	if tokenSecret == "" {
 		return "", fmt.Errorf("tokenSecret must not be blank")
 	}
 
-	claims := jwt.RegisteredClaims{
-		Issuer:	"chirpy",
-		IssuedAt: jwt.NewNumericDate(time.Now().UTC()),
-		ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(expiresIn)),
-		Subject: userID.String(),
+	claims := AccessClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:	"chirpy",
+			IssuedAt: jwt.NewNumericDate(time.Now().UTC()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(expiresIn)),
+			Subject: userID.String(),
+		},
+		ClientID:	clientID,
+		Scope:		scope,
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -47,23 +63,49 @@ func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (str
 	return tokenString, nil
 }
 
-func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
-	claims := &jwt.RegisteredClaims{}
+func ParseAccessToken(tokenString, tokenSecret string) (*AccessClaims, uuid.UUID, error) {
+	claims := &AccessClaims{}
 	token, err := jwt.ParseWithClaims(tokenString, claims, func (*jwt.Token) (interface{}, error) {
 		return []byte(tokenSecret), nil
-	})
+	}, jwt.WithIssuer("chirpy"))
 	if err != nil {
-		return uuid.UUID{}, fmt.Errorf("Error: token is malformed, expired, or tampered -- %w", err)
+		return nil, uuid.UUID{}, fmt.Errorf("Error: token is malformed, expired, or tampered -- %w", err)
 	} else if !token.Valid {
-		return uuid.UUID{}, fmt.Errorf("Error: token is invalid")
+		return nil, uuid.UUID{}, fmt.Errorf("Error: token is invalid")
 	}
 
 	if claims.Subject == "" {
-		return uuid.UUID{}, fmt.Errorf("Error: no subject")
+		return nil, uuid.UUID{}, fmt.Errorf("Error: no subject")
 	}
 	id, err := uuid.Parse(claims.Subject)
 	if err != nil {
-		return uuid.UUID{}, fmt.Errorf("Error parsing UUID: %w", err)
+		return nil, uuid.UUID{}, fmt.Errorf("Error parsing UUID: %w", err)
+	}
+
+	return claims, id, nil
+}
+
+// ValidateJWT only accepts first-party tokens. Endpoints that third-party clients may
+// call use ValidateScopedJWT instead.
+func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
+	claims, id, err := ParseAccessToken(tokenString, tokenSecret)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+	if claims.ClientID != "" {
+		return uuid.UUID{}, fmt.Errorf("Error: token was issued to an OAuth client")
+	}
+
+	return id, nil
+}
+
+func ValidateScopedJWT(tokenString, tokenSecret, scope string) (uuid.UUID, error) {
+	claims, id, err := ParseAccessToken(tokenString, tokenSecret)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+	if !claims.HasScope(scope) {
+		return uuid.UUID{}, fmt.Errorf("Error: token does not have the %q scope", scope)
 	}
 
 	return id, nil
@@ -0,0 +1,175 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	AlgorithmBcrypt		= "bcrypt"
+	AlgorithmArgon2id	= "argon2id"
+)
+
+type Argon2Params struct {
+	Memory		uint32
+	Iterations	uint32
+	Parallelism	uint8
+	SaltLength	uint32
+	KeyLength	uint32
+}
+
+var DefaultArgon2Params = Argon2Params{
+	Memory:		64 * 1024,
+	Iterations:	1,
+	Parallelism:	4,
+	SaltLength:	16,
+	KeyLength:	32,
+}
+
+// PasswordHasher hashes new passwords with the preferred algorithm and settings. Every
+// hash records the algorithm and parameters it was made with, so older hashes can still
+// be checked and NeedsRehash can tell when one should be upgraded.
+type PasswordHasher struct {
+	Algorithm	string
+	BcryptCost	int
+	Argon2		Argon2Params
+}
+
+var DefaultHasher = PasswordHasher{
+	Algorithm:	AlgorithmBcrypt,
+	BcryptCost:	bcrypt.DefaultCost,
+	Argon2:		DefaultArgon2Params,
+}
+
+func HashPassword(password string) (string, error) {
+	return DefaultHasher.Hash(password)
+}
+
+// CheckPasswordHash accepts a hash made by any supported algorithm
+func CheckPasswordHash(hash, password string) error {
+	if strings.HasPrefix(hash, "$argon2id$") {
+		return checkArgon2id(hash, password)
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+}
+
+func (h PasswordHasher) Hash(password string) (string, error) {
+	switch h.Algorithm {
+	case AlgorithmArgon2id:
+		return hashArgon2id(password, h.Argon2)
+	case AlgorithmBcrypt, "":
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), h.bcryptCost())
+		if err != nil {
+			return "", fmt.Errorf("Error hashing password: %w", err)
+		}
+		return string(hashedPassword), nil
+	default:
+		return "", fmt.Errorf("Error hashing password: unknown algorithm %q", h.Algorithm)
+	}
+}
+
+func (h PasswordHasher) Check(hash, password string) error {
+	return CheckPasswordHash(hash, password)
+}
+
+// NeedsRehash reports whether hash was made with a different algorithm or different
+// settings than the hasher currently uses. Settings that were lowered count too, so
+// hashes always follow the configuration.
+func (h PasswordHasher) NeedsRehash(hash string) bool {
+	switch h.Algorithm {
+	case AlgorithmArgon2id:
+		params, _, _, err := decodeArgon2id(hash)
+		if err != nil {
+			return true
+		}
+		return params.Memory != h.Argon2.Memory ||
+			params.Iterations != h.Argon2.Iterations ||
+			params.Parallelism != h.Argon2.Parallelism ||
+			params.KeyLength != h.Argon2.KeyLength
+	case AlgorithmBcrypt, "":
+		cost, err := bcrypt.Cost([]byte(hash))
+		if err != nil {
+			return true
+		}
+		return cost != h.bcryptCost()
+	default:
+		return false
+	}
+}
+
+func (h PasswordHasher) bcryptCost() int {
+	if h.BcryptCost == 0 {
+		return bcrypt.DefaultCost
+	}
+	return h.BcryptCost
+}
+
+func hashArgon2id(password string, params Argon2Params) (string, error) {
+	salt := make([]byte, params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("Error hashing password: %w", err)
+	}
+
+	key := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		params.Memory,
+		params.Iterations,
+		params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func checkArgon2id(hash, password string) error {
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return err
+	}
+
+	otherKey := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+	if subtle.ConstantTimeCompare(key, otherKey) != 1 {
+		return fmt.Errorf("Error: password does not match hash")
+	}
+
+	return nil
+}
+
+// decodeArgon2id parses the PHC string format "$argon2id$v=19$m=65536,t=1,p=4$salt$key"
+func decodeArgon2id(hash string) (Argon2Params, []byte, []byte, error) {
+	var params Argon2Params
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != AlgorithmArgon2id {
+		return params, nil, nil, fmt.Errorf("Error: hash is not in argon2id format")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return params, nil, nil, fmt.Errorf("Error parsing argon2id version: %w", err)
+	} else if version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("Error: unsupported argon2id version %d", version)
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, fmt.Errorf("Error parsing argon2id parameters: %w", err)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("Error decoding argon2id salt: %w", err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("Error decoding argon2id key: %w", err)
+	}
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+
+	return params, salt, key, nil
+}
@@ -0,0 +1,276 @@
+package auth
+
+import (
+	"io"
+	"os"
+	"fmt"
+	"math"
+	"bytes"
+	"strings"
+	"unicode"
+	"crypto/sha1"
+	"encoding/hex"
+)
+
+type PasswordPolicy struct {
+	MinLength	int
+	MinEntropyBits	float64
+	DisallowEmail	bool
+	// Breached is optional. When set, passwords found in the breach list are rejected.
+	Breached	BreachedPasswordChecker
+}
+
+// PasswordPolicyError lists every rule a password failed so clients can show them all at once
+type PasswordPolicyError struct {
+	Problems	[]string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return "Password does not meet the password policy: " + strings.Join(e.Problems, "; ")
+}
+
+func (p PasswordPolicy) Validate(password, email string) error {
+	var problems []string
+
+	length := len([]rune(password))
+	if length == 0 {
+		problems = append(problems, "password must not be empty")
+	} else if length < p.MinLength {
+		problems = append(problems, fmt.Sprintf("password must be at least %d characters long", p.MinLength))
+	}
+
+	if length > 0 && EstimateEntropy(password) < p.MinEntropyBits {
+		problems = append(problems, "password is too easy to guess, use a longer password or mix letters, digits and symbols")
+	}
+
+	if p.DisallowEmail && email != "" {
+		lowerPassword := strings.ToLower(password)
+		lowerEmail := strings.ToLower(email)
+		localPart, _, _ := strings.Cut(lowerEmail, "@")
+		if lowerPassword == lowerEmail || lowerPassword == localPart {
+			problems = append(problems, "password must not be your email address")
+		}
+	}
+
+	if p.Breached != nil && length > 0 {
+		breached, err := IsBreachedPassword(p.Breached, password)
+		if err != nil {
+			return fmt.Errorf("Error checking breached passwords: %w", err)
+		}
+		if breached {
+			problems = append(problems, "password has appeared in a data breach, choose a different one")
+		}
+	}
+
+	if len(problems) > 0 {
+		return &PasswordPolicyError{Problems: problems}
+	}
+
+	return nil
+}
+
+// EstimateEntropy gives a rough strength estimate in bits based on the size of the
+// character pool in use. Repeated characters in a row only count once.
+func EstimateEntropy(password string) float64 {
+	var hasLower, hasUpper, hasDigit, hasSymbol, hasOther bool
+	effectiveLength := 0
+	var prev rune = -1
+	for _, r := range password {
+		switch {
+		case r <= unicode.MaxASCII && unicode.IsLower(r):
+			hasLower = true
+		case r <= unicode.MaxASCII && unicode.IsUpper(r):
+			hasUpper = true
+		case r <= unicode.MaxASCII && unicode.IsDigit(r):
+			hasDigit = true
+		case r <= unicode.MaxASCII:
+			hasSymbol = true
+		default:
+			hasOther = true
+		}
+		if r != prev {
+			effectiveLength++
+		}
+		prev = r
+	}
+
+	pool := 0
+	if hasLower {
+		pool += 26
+	}
+	if hasUpper {
+		pool += 26
+	}
+	if hasDigit {
+		pool += 10
+	}
+	if hasSymbol {
+		pool += 33
+	}
+	if hasOther {
+		pool += 100
+	}
+	if pool == 0 {
+		return 0
+	}
+
+	return float64(effectiveLength) * math.Log2(float64(pool))
+}
+
+// BreachedPasswordChecker follows the k-anonymity model: callers only reveal the first
+// five hex characters of the password's SHA-1 hash and get back every known suffix.
+type BreachedPasswordChecker interface {
+	Range(prefix string) ([]string, error)
+}
+
+func IsBreachedPassword(checker BreachedPasswordChecker, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	suffixes, err := checker.Range(prefix)
+	if err != nil {
+		return false, err
+	}
+	for _, s := range suffixes {
+		if s == suffix {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// BreachedPasswordFile is a local breach list in the "HASH:COUNT" line format used by
+// the Have I Been Pwned downloader. The count is optional. The file must be sorted by
+// hash, as the downloader writes it, because it is binary searched on disk rather than
+// loaded into memory: the full list is tens of gigabytes.
+type BreachedPasswordFile struct {
+	file	*os.File
+	size	int64
+}
+
+func LoadBreachedPasswordFile(path string) (*BreachedPasswordFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error opening breached password file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("Error opening breached password file: %w", err)
+	}
+
+	list := &BreachedPasswordFile{file: file, size: info.Size()}
+	if list.size > 0 {
+		line, _, err := list.readLine(0)
+		if err == nil {
+			_, err = breachedLineHash(line)
+		}
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+
+	return list, nil
+}
+
+func (f *BreachedPasswordFile) Close() error {
+	return f.file.Close()
+}
+
+// Range binary searches for the first line at or after prefix and reads lines from
+// there until the prefix changes
+func (f *BreachedPasswordFile) Range(prefix string) ([]string, error) {
+	prefix = strings.ToUpper(prefix)
+
+	// Find the smallest offset whose following line starts with prefix or sorts after it
+	low, high := int64(0), f.size
+	for low < high {
+		mid := low + (high-low)/2
+		start, err := f.lineStart(mid)
+		if err != nil {
+			return nil, err
+		}
+		if start >= f.size {
+			high = mid
+			continue
+		}
+		line, _, err := f.readLine(start)
+		if err != nil {
+			return nil, err
+		}
+		hash, err := breachedLineHash(line)
+		if err != nil {
+			return nil, err
+		}
+		if hash[:5] < prefix {
+			low = start + 1
+		} else {
+			high = mid
+		}
+	}
+
+	offset, err := f.lineStart(low)
+	if err != nil {
+		return nil, err
+	}
+	suffixes := []string{}
+	for offset < f.size {
+		line, next, err := f.readLine(offset)
+		if err != nil {
+			return nil, err
+		}
+		hash, err := breachedLineHash(line)
+		if err != nil {
+			return nil, err
+		}
+		if hash[:5] != prefix {
+			break
+		}
+		suffixes = append(suffixes, hash[5:])
+		offset = next
+	}
+
+	return suffixes, nil
+}
+
+// lineStart returns the offset of the first line starting at or after offset
+func (f *BreachedPasswordFile) lineStart(offset int64) (int64, error) {
+	if offset == 0 {
+		return 0, nil
+	}
+	_, next, err := f.readLine(offset - 1)
+	return next, err
+}
+
+// readLine reads from offset up to the end of the line and returns the offset of the
+// next line
+func (f *BreachedPasswordFile) readLine(offset int64) (string, int64, error) {
+	var line []byte
+	buf := make([]byte, 64)
+	for offset < f.size {
+		n, err := f.file.ReadAt(buf, offset)
+		if i := bytes.IndexByte(buf[:n], '\n'); i >= 0 {
+			return string(append(line, buf[:i]...)), offset + int64(i) + 1, nil
+		}
+		line = append(line, buf[:n]...)
+		offset += int64(n)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return "", 0, fmt.Errorf("Error reading breached password file: %w", err)
+		}
+	}
+	return string(line), f.size, nil
+}
+
+func breachedLineHash(line string) (string, error) {
+	hash, _, _ := strings.Cut(strings.TrimSpace(line), ":")
+	hash = strings.ToUpper(hash)
+	if len(hash) != 40 {
+		return "", fmt.Errorf("Error reading breached password file: invalid hash %q", hash)
+	}
+	return hash, nil
+}
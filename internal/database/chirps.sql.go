@@ -7,29 +7,40 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 const createChirp = `-- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, visibility, content_warning, body_words, content_warning_words, published_at)
 VALUES (
 	gen_random_uuid(),
 	NOW(),
 	NOW(),
 	$1,
-	$2
+	$2,
+	$3,
+	$4,
+	$5,
+	$6,
+	NOW()
 )
-RETURNING id, created_at, updated_at, body, user_id
+RETURNING id, created_at, updated_at, body, user_id, publish_at, published_at, visibility, content_warning, body_words, content_warning_words
 `
 
 type CreateChirpParams struct {
-	Body   string    `json:"body"`
-	UserID uuid.UUID `json:"user_id"`
+	Body                string    `json:"body"`
+	UserID              uuid.UUID `json:"user_id"`
+	Visibility          string    `json:"visibility"`
+	ContentWarning      string    `json:"content_warning"`
+	BodyWords           string    `json:"body_words"`
+	ContentWarningWords string    `json:"content_warning_words"`
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID, arg.Visibility, arg.ContentWarning, arg.BodyWords, arg.ContentWarningWords)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -37,6 +48,77 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.PublishAt,
+		&i.PublishedAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.BodyWords,
+		&i.ContentWarningWords,
+	)
+	return i, err
+}
+
+const createChirpMention = `-- name: CreateChirpMention :exec
+INSERT INTO chirp_mentions (chirp_id, user_id)
+VALUES (
+	$1,
+	$2
+)
+ON CONFLICT DO NOTHING
+`
+
+type CreateChirpMentionParams struct {
+	ChirpID uuid.UUID `json:"chirp_id"`
+	UserID  uuid.UUID `json:"user_id"`
+}
+
+func (q *Queries) CreateChirpMention(ctx context.Context, arg CreateChirpMentionParams) error {
+	_, err := q.db.ExecContext(ctx, createChirpMention, arg.ChirpID, arg.UserID)
+	return err
+}
+
+const createScheduledChirp = `-- name: CreateScheduledChirp :one
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, visibility, content_warning, body_words, content_warning_words, publish_at)
+VALUES (
+	gen_random_uuid(),
+	NOW(),
+	NOW(),
+	$1,
+	$2,
+	$3,
+	$4,
+	$5,
+	$6,
+	$7
+)
+RETURNING id, created_at, updated_at, body, user_id, publish_at, published_at, visibility, content_warning, body_words, content_warning_words
+`
+
+type CreateScheduledChirpParams struct {
+	Body                string       `json:"body"`
+	UserID              uuid.UUID    `json:"user_id"`
+	Visibility          string       `json:"visibility"`
+	ContentWarning      string       `json:"content_warning"`
+	BodyWords           string       `json:"body_words"`
+	ContentWarningWords string       `json:"content_warning_words"`
+	PublishAt           sql.NullTime `json:"publish_at"`
+}
+
+func (q *Queries) CreateScheduledChirp(ctx context.Context, arg CreateScheduledChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, createScheduledChirp, arg.Body, arg.UserID, arg.Visibility, arg.ContentWarning, arg.BodyWords, arg.ContentWarningWords, arg.PublishAt)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.PublishAt,
+		&i.PublishedAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.BodyWords,
+		&i.ContentWarningWords,
 	)
 	return i, err
 }
@@ -51,11 +133,18 @@ func (q *Queries) DeleteChirp(ctx context.Context, id uuid.UUID) error {
 }
 
 const getChirp = `-- name: GetChirp :one
-SELECT id, created_at, updated_at, body, user_id FROM chirps WHERE id = $1
+SELECT id, created_at, updated_at, body, user_id, publish_at, published_at, visibility, content_warning, body_words, content_warning_words FROM chirps
+WHERE id = $1
+	AND chirp_visible_to(id, user_id, visibility, published_at, $2::uuid)
 `
 
-func (q *Queries) GetChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, getChirp, id)
+type GetChirpParams struct {
+	ID       uuid.UUID `json:"id"`
+	ViewerID uuid.UUID `json:"viewer_id"`
+}
+
+func (q *Queries) GetChirp(ctx context.Context, arg GetChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getChirp, arg.ID, arg.ViewerID)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -63,16 +152,33 @@ func (q *Queries) GetChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.PublishAt,
+		&i.PublishedAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.BodyWords,
+		&i.ContentWarningWords,
 	)
 	return i, err
 }
 
 const getChirps = `-- name: GetChirps :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps ORDER BY created_at
+SELECT id, created_at, updated_at, body, user_id, publish_at, published_at, visibility, content_warning, body_words, content_warning_words FROM chirps
+WHERE published_at IS NOT NULL
+	AND (visibility <> 'unlisted' OR user_id = $1)
+	AND chirp_visible_to(id, user_id, visibility, published_at, $1::uuid)
+	AND NOT chirp_muted_for(body_words, content_warning_words, user_id, $1::uuid)
+	AND NOT EXISTS (
+		SELECT 1 FROM mutes WHERE muter_id = $1 AND muted_id = chirps.user_id
+	)
+	AND NOT EXISTS (
+		SELECT 1 FROM blocks WHERE blocker_id = $1 AND blocked_id = chirps.user_id
+	)
+ORDER BY created_at
 `
 
-func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getChirps)
+func (q *Queries) GetChirps(ctx context.Context, viewerID uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirps, viewerID)
 	if err != nil {
 		return nil, err
 	}
@@ -86,6 +192,12 @@ func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.PublishAt,
+			&i.PublishedAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.BodyWords,
+			&i.ContentWarningWords,
 		); err != nil {
 			return nil, err
 		}
@@ -101,11 +213,145 @@ func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
 }
 
 const getChirpsByUser = `-- name: GetChirpsByUser :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps WHERE user_id = $1 ORDER BY created_at
+SELECT id, created_at, updated_at, body, user_id, publish_at, published_at, visibility, content_warning, body_words, content_warning_words FROM chirps
+WHERE user_id = $1
+	AND published_at IS NOT NULL
+	AND chirp_visible_to(id, user_id, visibility, published_at, $2::uuid)
+	AND NOT chirp_muted_for(body_words, content_warning_words, user_id, $2::uuid)
+ORDER BY created_at
+`
+
+type GetChirpsByUserParams struct {
+	UserID   uuid.UUID `json:"user_id"`
+	ViewerID uuid.UUID `json:"viewer_id"`
+}
+
+func (q *Queries) GetChirpsByUser(ctx context.Context, arg GetChirpsByUserParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByUser, arg.UserID, arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.PublishAt,
+			&i.PublishedAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.BodyWords,
+			&i.ContentWarningWords,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getScheduledChirpsByUser = `-- name: GetScheduledChirpsByUser :many
+SELECT id, created_at, updated_at, body, user_id, publish_at, published_at, visibility, content_warning, body_words, content_warning_words FROM chirps WHERE user_id = $1 AND published_at IS NULL ORDER BY publish_at
+`
+
+func (q *Queries) GetScheduledChirpsByUser(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getScheduledChirpsByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.PublishAt,
+			&i.PublishedAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.BodyWords,
+			&i.ContentWarningWords,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const importChirp = `-- name: ImportChirp :one
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, visibility, body_words, content_warning, content_warning_words, published_at)
+SELECT gen_random_uuid(), $1::timestamp, $1::timestamp, $2::text, $3::uuid, $4::text, $5::text, $6::text, $7::text, $1::timestamp
+WHERE NOT EXISTS (
+	SELECT 1 FROM chirps WHERE user_id = $3 AND body = $2 AND created_at = $1
+)
+RETURNING id, created_at, updated_at, body, user_id, publish_at, published_at, visibility, content_warning, body_words, content_warning_words
+`
+
+type ImportChirpParams struct {
+	CreatedAt           time.Time `json:"created_at"`
+	Body                string    `json:"body"`
+	UserID              uuid.UUID `json:"user_id"`
+	Visibility          string    `json:"visibility"`
+	BodyWords           string    `json:"body_words"`
+	ContentWarning      string    `json:"content_warning"`
+	ContentWarningWords string    `json:"content_warning_words"`
+}
+
+func (q *Queries) ImportChirp(ctx context.Context, arg ImportChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, importChirp, arg.CreatedAt, arg.Body, arg.UserID, arg.Visibility, arg.BodyWords, arg.ContentWarning, arg.ContentWarningWords)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.PublishAt,
+		&i.PublishedAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.BodyWords,
+		&i.ContentWarningWords,
+	)
+	return i, err
+}
+
+const publishDueChirps = `-- name: PublishDueChirps :many
+UPDATE chirps SET created_at = NOW(), updated_at = NOW(), published_at = NOW()
+WHERE published_at IS NULL AND id IN (
+	SELECT id FROM chirps
+	WHERE published_at IS NULL AND publish_at <= NOW()
+	ORDER BY publish_at
+	LIMIT 100
+	FOR UPDATE SKIP LOCKED
+)
+RETURNING id, created_at, updated_at, body, user_id, publish_at, published_at, visibility, content_warning, body_words, content_warning_words
 `
 
-func (q *Queries) GetChirpsByUser(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getChirpsByUser, userID)
+func (q *Queries) PublishDueChirps(ctx context.Context) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, publishDueChirps)
 	if err != nil {
 		return nil, err
 	}
@@ -119,6 +365,12 @@ func (q *Queries) GetChirpsByUser(ctx context.Context, userID uuid.UUID) ([]Chir
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.PublishAt,
+			&i.PublishedAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.BodyWords,
+			&i.ContentWarningWords,
 		); err != nil {
 			return nil, err
 		}
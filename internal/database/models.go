@@ -11,28 +11,267 @@ import (
 	"github.com/google/uuid"
 )
 
+type Block struct {
+	BlockerID uuid.UUID `json:"blocker_id"`
+	BlockedID uuid.UUID `json:"blocked_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Bookmark struct {
+	UserID    uuid.UUID `json:"user_id"`
+	ChirpID   uuid.UUID `json:"chirp_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 type Chirp struct {
+	ID                  uuid.UUID    `json:"id"`
+	CreatedAt           time.Time    `json:"created_at"`
+	UpdatedAt           time.Time    `json:"updated_at"`
+	Body                string       `json:"body"`
+	UserID              uuid.UUID    `json:"user_id"`
+	PublishAt           sql.NullTime `json:"publish_at"`
+	PublishedAt         sql.NullTime `json:"published_at"`
+	Visibility          string       `json:"visibility"`
+	ContentWarning      string       `json:"content_warning"`
+	BodyWords           string       `json:"body_words"`
+	ContentWarningWords string       `json:"content_warning_words"`
+}
+
+type ChirpMedium struct {
+	ID           uuid.UUID     `json:"id"`
+	CreatedAt    time.Time     `json:"created_at"`
+	UserID       uuid.UUID     `json:"user_id"`
+	ChirpID      uuid.NullUUID `json:"chirp_id"`
+	Position     int32         `json:"position"`
+	ContentType  string        `json:"content_type"`
+	BlobKey      string        `json:"blob_key"`
+	ThumbnailKey string        `json:"thumbnail_key"`
+	Width        int32         `json:"width"`
+	Height       int32         `json:"height"`
+	AltText      string        `json:"alt_text"`
+	Blurhash     string        `json:"blurhash"`
+}
+
+type ChirpMention struct {
+	ChirpID uuid.UUID `json:"chirp_id"`
+	UserID  uuid.UUID `json:"user_id"`
+}
+
+type DataExport struct {
+	ID            uuid.UUID      `json:"id"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	UserID        uuid.UUID      `json:"user_id"`
+	Status        string         `json:"status"`
+	Archive       []byte         `json:"archive"`
+	DownloadToken sql.NullString `json:"download_token"`
+	ExpiresAt     sql.NullTime   `json:"expires_at"`
+}
+
+type Draft struct {
+	ID             uuid.UUID    `json:"id"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+	UserID         uuid.UUID    `json:"user_id"`
+	Body           string       `json:"body"`
+	MediaIds       []uuid.UUID  `json:"media_ids"`
+	PollOptions    []string     `json:"poll_options"`
+	PollClosesAt   sql.NullTime `json:"poll_closes_at"`
+	PublishAt      sql.NullTime `json:"publish_at"`
+	Visibility     string       `json:"visibility"`
+	ContentWarning string       `json:"content_warning"`
+}
+
+type EmailVerification struct {
+	Token     string       `json:"token"`
+	CreatedAt time.Time    `json:"created_at"`
+	UserID    uuid.UUID    `json:"user_id"`
+	Email     string       `json:"email"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	UsedAt    sql.NullTime `json:"used_at"`
+}
+
+type Follow struct {
+	FollowerID uuid.UUID `json:"follower_id"`
+	FolloweeID uuid.UUID `json:"followee_id"`
+	CreatedAt  time.Time `json:"created_at"`
+	Status     string    `json:"status"`
+}
+
+type ImportJob struct {
+	ID          uuid.UUID      `json:"id"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	UserID      uuid.UUID      `json:"user_id"`
+	Source      string         `json:"source"`
+	Status      string         `json:"status"`
+	Total       int32          `json:"total"`
+	Imported    int32          `json:"imported"`
+	Duplicates  int32          `json:"duplicates"`
+	Censored    int32          `json:"censored"`
+	Skipped     int32          `json:"skipped"`
+	Error       sql.NullString `json:"error"`
+	CompletedAt sql.NullTime   `json:"completed_at"`
+}
+
+type ImportJobIssue struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	JobID     uuid.UUID `json:"job_id"`
+	SourceID  string    `json:"source_id"`
+	Reason    string    `json:"reason"`
+}
+
+type LinkPreview struct {
+	Url         string    `json:"url"`
+	FetchedAt   time.Time `json:"fetched_at"`
+	Status      string    `json:"status"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	ImageUrl    string    `json:"image_url"`
+	SiteName    string    `json:"site_name"`
+}
+
+type List struct {
 	ID        uuid.UUID `json:"id"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
-	Body      string    `json:"body"`
 	UserID    uuid.UUID `json:"user_id"`
+	Name      string    `json:"name"`
+	Private   bool      `json:"private"`
 }
 
-type RefreshToken struct {
-	Token     string       `json:"token"`
+type ListMember struct {
+	ListID    uuid.UUID `json:"list_id"`
+	UserID    uuid.UUID `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type LoginFailure struct {
+	Key         string       `json:"key"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+	Failures    int32        `json:"failures"`
+	LockedUntil sql.NullTime `json:"locked_until"`
+}
+
+type Mute struct {
+	MuterID   uuid.UUID `json:"muter_id"`
+	MutedID   uuid.UUID `json:"muted_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type MutedKeyword struct {
+	ID        uuid.UUID    `json:"id"`
+	UserID    uuid.UUID    `json:"user_id"`
+	Phrase    string       `json:"phrase"`
+	CreatedAt time.Time    `json:"created_at"`
+	ExpiresAt sql.NullTime `json:"expires_at"`
+}
+
+type OauthAuthorizationCode struct {
+	Code          string    `json:"code"`
+	CreatedAt     time.Time `json:"created_at"`
+	ClientID      uuid.UUID `json:"client_id"`
+	UserID        uuid.UUID `json:"user_id"`
+	RedirectUri   string    `json:"redirect_uri"`
+	Scope         string    `json:"scope"`
+	CodeChallenge string    `json:"code_challenge"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
+
+type OauthClient struct {
+	ID               uuid.UUID      `json:"id"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	UserID           uuid.UUID      `json:"user_id"`
+	Name             string         `json:"name"`
+	ClientSecretHash sql.NullString `json:"client_secret_hash"`
+	RedirectUris     []string       `json:"redirect_uris"`
+}
+
+type OidcLoginState struct {
+	State        string    `json:"state"`
+	CreatedAt    time.Time `json:"created_at"`
+	Provider     string    `json:"provider"`
+	Nonce        string    `json:"nonce"`
+	CodeVerifier string    `json:"code_verifier"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+type Poll struct {
+	ID       uuid.UUID `json:"id"`
+	ChirpID  uuid.UUID `json:"chirp_id"`
+	ClosesAt time.Time `json:"closes_at"`
+}
+
+type PollOption struct {
+	ID       uuid.UUID `json:"id"`
+	PollID   uuid.UUID `json:"poll_id"`
+	Position int32     `json:"position"`
+	Label    string    `json:"label"`
+}
+
+type PollVote struct {
+	PollID    uuid.UUID `json:"poll_id"`
+	UserID    uuid.UUID `json:"user_id"`
+	OptionID  uuid.UUID `json:"option_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type RecoveryCode struct {
+	ID        uuid.UUID    `json:"id"`
 	CreatedAt time.Time    `json:"created_at"`
-	UpdatedAt time.Time    `json:"updated_at"`
 	UserID    uuid.UUID    `json:"user_id"`
-	ExpiresAt time.Time    `json:"expires_at"`
-	RevokedAt sql.NullTime `json:"revoked_at"`
+	CodeHash  string       `json:"code_hash"`
+	UsedAt    sql.NullTime `json:"used_at"`
+}
+
+type RefreshToken struct {
+	Token     string        `json:"token"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+	UserID    uuid.UUID     `json:"user_id"`
+	ExpiresAt time.Time     `json:"expires_at"`
+	RevokedAt sql.NullTime  `json:"revoked_at"`
+	ClientID  uuid.NullUUID `json:"client_id"`
+	Scope     string        `json:"scope"`
 }
 
 type User struct {
-	ID             uuid.UUID `json:"id"`
-	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
-	Email          string    `json:"email"`
-	HashedPassword string    `json:"hashed_password"`
-	IsChirpyRed    bool      `json:"is_chirpy_red"`
+	ID                   uuid.UUID      `json:"id"`
+	CreatedAt            time.Time      `json:"created_at"`
+	UpdatedAt            time.Time      `json:"updated_at"`
+	Email                string         `json:"email"`
+	HashedPassword       string         `json:"hashed_password"`
+	IsChirpyRed          bool           `json:"is_chirpy_red"`
+	EmailVerifiedAt      sql.NullTime   `json:"email_verified_at"`
+	DeletionScheduledFor sql.NullTime   `json:"deletion_scheduled_for"`
+	Handle               string         `json:"handle"`
+	DisplayName          string         `json:"display_name"`
+	Bio                  string         `json:"bio"`
+	Location             string         `json:"location"`
+	Website              string         `json:"website"`
+	AvatarKey            sql.NullString `json:"avatar_key"`
+	HeaderKey            sql.NullString `json:"header_key"`
+	Protected            bool           `json:"protected"`
+	PinnedChirpID        uuid.NullUUID  `json:"pinned_chirp_id"`
+}
+
+type UserIdentity struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UserID    uuid.UUID `json:"user_id"`
+	Provider  string    `json:"provider"`
+	Subject   string    `json:"subject"`
+	Email     string    `json:"email"`
+}
+
+type UserTotp struct {
+	UserID       uuid.UUID    `json:"user_id"`
+	CreatedAt    time.Time    `json:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+	Secret       string       `json:"secret"`
+	ConfirmedAt  sql.NullTime `json:"confirmed_at"`
+	LastUsedStep int64        `json:"last_used_step"`
 }
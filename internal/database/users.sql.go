@@ -7,10 +7,21 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const cancelUserDeletion = `-- name: CancelUserDeletion :exec
+UPDATE users SET deletion_scheduled_for = NULL, updated_at = NOW() WHERE id = $1
+`
+
+func (q *Queries) CancelUserDeletion(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, cancelUserDeletion, id)
+	return err
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (id, created_at, updated_at,  email, hashed_password)
 VALUES (
@@ -20,7 +31,7 @@ VALUES (
 	$1,
 	$2
 )
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, email_verified_at, deletion_scheduled_for, handle, display_name, bio, location, website, avatar_key, header_key, protected, pinned_chirp_id
 `
 
 type CreateUserParams struct {
@@ -38,12 +49,154 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.EmailVerifiedAt,
+		&i.DeletionScheduledFor,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarKey,
+		&i.HeaderKey,
+		&i.Protected,
+		&i.PinnedChirpID,
+	)
+	return i, err
+}
+
+const createUserWithVerifiedEmail = `-- name: CreateUserWithVerifiedEmail :one
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, email_verified_at)
+VALUES (
+	gen_random_uuid(),
+	NOW(),
+	NOW(),
+	$1,
+	'unset',
+	NOW()
+)
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, email_verified_at, deletion_scheduled_for, handle, display_name, bio, location, website, avatar_key, header_key, protected, pinned_chirp_id
+`
+
+func (q *Queries) CreateUserWithVerifiedEmail(ctx context.Context, email string) (User, error) {
+	row := q.db.QueryRowContext(ctx, createUserWithVerifiedEmail, email)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.EmailVerifiedAt,
+		&i.DeletionScheduledFor,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarKey,
+		&i.HeaderKey,
+		&i.Protected,
+		&i.PinnedChirpID,
 	)
 	return i, err
 }
 
+const deleteUsersScheduledForDeletion = `-- name: DeleteUsersScheduledForDeletion :many
+DELETE FROM users WHERE deletion_scheduled_for <= NOW()
+RETURNING id, avatar_key, header_key
+`
+
+type DeleteUsersScheduledForDeletionRow struct {
+	ID        uuid.UUID      `json:"id"`
+	AvatarKey sql.NullString `json:"avatar_key"`
+	HeaderKey sql.NullString `json:"header_key"`
+}
+
+func (q *Queries) DeleteUsersScheduledForDeletion(ctx context.Context) ([]DeleteUsersScheduledForDeletionRow, error) {
+	rows, err := q.db.QueryContext(ctx, deleteUsersScheduledForDeletion)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DeleteUsersScheduledForDeletionRow
+	for rows.Next() {
+		var i DeleteUsersScheduledForDeletionRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.AvatarKey,
+			&i.HeaderKey,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMentionableUsers = `-- name: GetMentionableUsers :many
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, email_verified_at, deletion_scheduled_for, handle, display_name, bio, location, website, avatar_key, header_key, protected, pinned_chirp_id FROM users
+WHERE lower(handle) = ANY($1::text[])
+	AND id <> $2
+	AND NOT EXISTS (
+		SELECT 1 FROM blocks WHERE blocker_id = users.id AND blocked_id = $2
+	)
+`
+
+type GetMentionableUsersParams struct {
+	Handles  []string  `json:"handles"`
+	AuthorID uuid.UUID `json:"author_id"`
+}
+
+func (q *Queries) GetMentionableUsers(ctx context.Context, arg GetMentionableUsersParams) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, getMentionableUsers, pq.Array(arg.Handles), arg.AuthorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Email,
+			&i.HashedPassword,
+			&i.IsChirpyRed,
+			&i.EmailVerifiedAt,
+			&i.DeletionScheduledFor,
+			&i.Handle,
+			&i.DisplayName,
+			&i.Bio,
+			&i.Location,
+			&i.Website,
+			&i.AvatarKey,
+			&i.HeaderKey,
+			&i.Protected,
+			&i.PinnedChirpID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red FROM users WHERE email = $1
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, email_verified_at, deletion_scheduled_for, handle, display_name, bio, location, website, avatar_key, header_key, protected, pinned_chirp_id FROM users WHERE email = $1
 `
 
 func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
@@ -56,12 +209,52 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.EmailVerifiedAt,
+		&i.DeletionScheduledFor,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarKey,
+		&i.HeaderKey,
+		&i.Protected,
+		&i.PinnedChirpID,
+	)
+	return i, err
+}
+
+const getUserByHandle = `-- name: GetUserByHandle :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, email_verified_at, deletion_scheduled_for, handle, display_name, bio, location, website, avatar_key, header_key, protected, pinned_chirp_id FROM users WHERE lower(handle) = lower($1)
+`
+
+func (q *Queries) GetUserByHandle(ctx context.Context, handle string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByHandle, handle)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.EmailVerifiedAt,
+		&i.DeletionScheduledFor,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarKey,
+		&i.HeaderKey,
+		&i.Protected,
+		&i.PinnedChirpID,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red FROM users WHERE id = $1
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, email_verified_at, deletion_scheduled_for, handle, display_name, bio, location, website, avatar_key, header_key, protected, pinned_chirp_id FROM users WHERE id = $1
 `
 
 func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
@@ -74,6 +267,50 @@ func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.EmailVerifiedAt,
+		&i.DeletionScheduledFor,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarKey,
+		&i.HeaderKey,
+		&i.Protected,
+		&i.PinnedChirpID,
+	)
+	return i, err
+}
+
+const getUserStats = `-- name: GetUserStats :one
+SELECT
+	(SELECT COUNT(*) FROM follows WHERE followee_id = $1 AND status = 'approved') AS follower_count,
+	(SELECT COUNT(*) FROM follows WHERE follower_id = $1 AND status = 'approved') AS following_count,
+	(
+		SELECT COUNT(*) FROM chirps
+		WHERE user_id = $1 AND published_at IS NOT NULL
+			AND chirp_visible_to(id, user_id, visibility, published_at, $2::uuid)
+	) AS chirp_count
+`
+
+type GetUserStatsParams struct {
+	UserID   uuid.UUID `json:"user_id"`
+	ViewerID uuid.UUID `json:"viewer_id"`
+}
+
+type GetUserStatsRow struct {
+	FollowerCount  int64 `json:"follower_count"`
+	FollowingCount int64 `json:"following_count"`
+	ChirpCount     int64 `json:"chirp_count"`
+}
+
+func (q *Queries) GetUserStats(ctx context.Context, arg GetUserStatsParams) (GetUserStatsRow, error) {
+	row := q.db.QueryRowContext(ctx, getUserStats, arg.UserID, arg.ViewerID)
+	var i GetUserStatsRow
+	err := row.Scan(
+		&i.FollowerCount,
+		&i.FollowingCount,
+		&i.ChirpCount,
 	)
 	return i, err
 }
@@ -87,8 +324,48 @@ func (q *Queries) ResetUsers(ctx context.Context) error {
 	return err
 }
 
+const scheduleUserDeletion = `-- name: ScheduleUserDeletion :one
+UPDATE users SET deletion_scheduled_for = $2, updated_at = NOW() WHERE id = $1 RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, email_verified_at, deletion_scheduled_for, handle, display_name, bio, location, website, avatar_key, header_key, protected, pinned_chirp_id
+`
+
+type ScheduleUserDeletionParams struct {
+	ID                   uuid.UUID    `json:"id"`
+	DeletionScheduledFor sql.NullTime `json:"deletion_scheduled_for"`
+}
+
+func (q *Queries) ScheduleUserDeletion(ctx context.Context, arg ScheduleUserDeletionParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, scheduleUserDeletion, arg.ID, arg.DeletionScheduledFor)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.EmailVerifiedAt,
+		&i.DeletionScheduledFor,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarKey,
+		&i.HeaderKey,
+		&i.Protected,
+		&i.PinnedChirpID,
+	)
+	return i, err
+}
+
 const updateUser = `-- name: UpdateUser :one
-UPDATE users SET email = $2, hashed_password = $3, updated_at = NOW() WHERE id = $1 RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+UPDATE users
+SET email = $2,
+	hashed_password = $3,
+	email_verified_at = CASE WHEN email = $2 THEN email_verified_at ELSE NULL END,
+	updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, email_verified_at, deletion_scheduled_for, handle, display_name, bio, location, website, avatar_key, header_key, protected, pinned_chirp_id
 `
 
 type UpdateUserParams struct {
@@ -107,12 +384,219 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.EmailVerifiedAt,
+		&i.DeletionScheduledFor,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarKey,
+		&i.HeaderKey,
+		&i.Protected,
+		&i.PinnedChirpID,
+	)
+	return i, err
+}
+
+const updateUserAvatar = `-- name: UpdateUserAvatar :one
+UPDATE users SET avatar_key = $2, updated_at = NOW() WHERE id = $1 RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, email_verified_at, deletion_scheduled_for, handle, display_name, bio, location, website, avatar_key, header_key, protected, pinned_chirp_id
+`
+
+type UpdateUserAvatarParams struct {
+	ID        uuid.UUID      `json:"id"`
+	AvatarKey sql.NullString `json:"avatar_key"`
+}
+
+func (q *Queries) UpdateUserAvatar(ctx context.Context, arg UpdateUserAvatarParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserAvatar, arg.ID, arg.AvatarKey)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.EmailVerifiedAt,
+		&i.DeletionScheduledFor,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarKey,
+		&i.HeaderKey,
+		&i.Protected,
+		&i.PinnedChirpID,
+	)
+	return i, err
+}
+
+const updateUserHeader = `-- name: UpdateUserHeader :one
+UPDATE users SET header_key = $2, updated_at = NOW() WHERE id = $1 RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, email_verified_at, deletion_scheduled_for, handle, display_name, bio, location, website, avatar_key, header_key, protected, pinned_chirp_id
+`
+
+type UpdateUserHeaderParams struct {
+	ID        uuid.UUID      `json:"id"`
+	HeaderKey sql.NullString `json:"header_key"`
+}
+
+func (q *Queries) UpdateUserHeader(ctx context.Context, arg UpdateUserHeaderParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserHeader, arg.ID, arg.HeaderKey)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.EmailVerifiedAt,
+		&i.DeletionScheduledFor,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarKey,
+		&i.HeaderKey,
+		&i.Protected,
+		&i.PinnedChirpID,
+	)
+	return i, err
+}
+
+const updateUserPassword = `-- name: UpdateUserPassword :exec
+UPDATE users SET hashed_password = $2, updated_at = NOW() WHERE id = $1
+`
+
+type UpdateUserPasswordParams struct {
+	ID             uuid.UUID `json:"id"`
+	HashedPassword string    `json:"hashed_password"`
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserPassword, arg.ID, arg.HashedPassword)
+	return err
+}
+
+const updateUserPinnedChirp = `-- name: UpdateUserPinnedChirp :one
+UPDATE users SET pinned_chirp_id = $2, updated_at = NOW() WHERE id = $1 RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, email_verified_at, deletion_scheduled_for, handle, display_name, bio, location, website, avatar_key, header_key, protected, pinned_chirp_id
+`
+
+type UpdateUserPinnedChirpParams struct {
+	ID            uuid.UUID     `json:"id"`
+	PinnedChirpID uuid.NullUUID `json:"pinned_chirp_id"`
+}
+
+func (q *Queries) UpdateUserPinnedChirp(ctx context.Context, arg UpdateUserPinnedChirpParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserPinnedChirp, arg.ID, arg.PinnedChirpID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.EmailVerifiedAt,
+		&i.DeletionScheduledFor,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarKey,
+		&i.HeaderKey,
+		&i.Protected,
+		&i.PinnedChirpID,
+	)
+	return i, err
+}
+
+const updateUserProfile = `-- name: UpdateUserProfile :one
+UPDATE users
+SET handle = $2,
+	display_name = $3,
+	bio = $4,
+	location = $5,
+	website = $6,
+	updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, email_verified_at, deletion_scheduled_for, handle, display_name, bio, location, website, avatar_key, header_key, protected, pinned_chirp_id
+`
+
+type UpdateUserProfileParams struct {
+	ID          uuid.UUID `json:"id"`
+	Handle      string    `json:"handle"`
+	DisplayName string    `json:"display_name"`
+	Bio         string    `json:"bio"`
+	Location    string    `json:"location"`
+	Website     string    `json:"website"`
+}
+
+func (q *Queries) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserProfile, arg.ID, arg.Handle, arg.DisplayName, arg.Bio, arg.Location, arg.Website)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.EmailVerifiedAt,
+		&i.DeletionScheduledFor,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarKey,
+		&i.HeaderKey,
+		&i.Protected,
+		&i.PinnedChirpID,
+	)
+	return i, err
+}
+
+const updateUserProtected = `-- name: UpdateUserProtected :one
+UPDATE users SET protected = $2, updated_at = NOW() WHERE id = $1 RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, email_verified_at, deletion_scheduled_for, handle, display_name, bio, location, website, avatar_key, header_key, protected, pinned_chirp_id
+`
+
+type UpdateUserProtectedParams struct {
+	ID        uuid.UUID `json:"id"`
+	Protected bool      `json:"protected"`
+}
+
+func (q *Queries) UpdateUserProtected(ctx context.Context, arg UpdateUserProtectedParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserProtected, arg.ID, arg.Protected)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.EmailVerifiedAt,
+		&i.DeletionScheduledFor,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarKey,
+		&i.HeaderKey,
+		&i.Protected,
+		&i.PinnedChirpID,
 	)
 	return i, err
 }
 
 const upgradeUser = `-- name: UpgradeUser :one
-UPDATE users SET is_chirpy_red = true, updated_at = NOW() WHERE id = $1 RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+UPDATE users SET is_chirpy_red = true, updated_at = NOW() WHERE id = $1 RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, email_verified_at, deletion_scheduled_for, handle, display_name, bio, location, website, avatar_key, header_key, protected, pinned_chirp_id
 `
 
 func (q *Queries) UpgradeUser(ctx context.Context, id uuid.UUID) (User, error) {
@@ -125,6 +609,51 @@ func (q *Queries) UpgradeUser(ctx context.Context, id uuid.UUID) (User, error) {
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.EmailVerifiedAt,
+		&i.DeletionScheduledFor,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarKey,
+		&i.HeaderKey,
+		&i.Protected,
+		&i.PinnedChirpID,
+	)
+	return i, err
+}
+
+const verifyUserEmail = `-- name: VerifyUserEmail :one
+UPDATE users SET email_verified_at = NOW(), updated_at = NOW() WHERE id = $1 AND email = $2 RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, email_verified_at, deletion_scheduled_for, handle, display_name, bio, location, website, avatar_key, header_key, protected, pinned_chirp_id
+`
+
+type VerifyUserEmailParams struct {
+	ID    uuid.UUID `json:"id"`
+	Email string    `json:"email"`
+}
+
+func (q *Queries) VerifyUserEmail(ctx context.Context, arg VerifyUserEmailParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, verifyUserEmail, arg.ID, arg.Email)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.EmailVerifiedAt,
+		&i.DeletionScheduledFor,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarKey,
+		&i.HeaderKey,
+		&i.Protected,
+		&i.PinnedChirpID,
 	)
 	return i, err
 }
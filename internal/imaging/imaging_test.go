@@ -0,0 +1,112 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"testing"
+	"image/color"
+	"image/jpeg"
+	"encoding/binary"
+)
+
+// testJPEG encodes a width x height JPEG with an EXIF orientation tag
+func testJPEG(t *testing.T, width, height, orientation int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{R: 200, G: 100, B: 50, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatalf("Error encoding JPEG: %v", err)
+	}
+
+	// Little endian TIFF header, one IFD entry: orientation, SHORT, count 1
+	tiff := []byte("II*\x00\x08\x00\x00\x00\x01\x00")
+	entry := make([]byte, 12)
+	binary.LittleEndian.PutUint16(entry[0:], 0x0112)
+	binary.LittleEndian.PutUint16(entry[2:], 3)
+	binary.LittleEndian.PutUint32(entry[4:], 1)
+	binary.LittleEndian.PutUint16(entry[8:], uint16(orientation))
+	tiff = append(tiff, entry...)
+	tiff = append(tiff, 0, 0, 0, 0)
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	data := buf.Bytes()
+	out := append([]byte{}, data[:2]...)
+	out = append(out, segment...)
+	return append(out, data[2:]...)
+}
+
+func TestDecodeAppliesOrientation(t *testing.T) {
+	data := testJPEG(t, 40, 20, 6)
+
+	img, err := Decode(data)
+	if err != nil {
+		t.Fatalf("Error decoding: %v", err)
+	}
+	if img.Bounds().Dx() != 20 || img.Bounds().Dy() != 40 {
+		t.Errorf("Expected a 20x40 image after rotating, got %v", img.Bounds())
+	}
+
+	var buf bytes.Buffer
+	contentType, err := Encode(&buf, img)
+	if err != nil {
+		t.Fatalf("Error encoding: %v", err)
+	}
+	if contentType != "image/jpeg" {
+		t.Errorf("Expected an opaque image to be encoded as JPEG, got %s", contentType)
+	}
+	if bytes.Contains(buf.Bytes(), []byte("Exif")) {
+		t.Errorf("Expected EXIF data to be stripped")
+	}
+}
+
+func TestSniffRejectsOtherContent(t *testing.T) {
+	if _, err := Decode([]byte("<html><body>not an image</body></html>")); err != ErrUnsupportedFormat {
+		t.Errorf("Expected ErrUnsupportedFormat, got %v", err)
+	}
+}
+
+func TestFillAndFit(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 300, 100))
+
+	filled := Fill(src, 50, 50)
+	if filled.Bounds().Dx() != 50 || filled.Bounds().Dy() != 50 {
+		t.Errorf("Expected Fill to return 50x50, got %v", filled.Bounds())
+	}
+
+	fitted := Fit(src, 150, 150)
+	if fitted.Bounds().Dx() != 150 || fitted.Bounds().Dy() != 50 {
+		t.Errorf("Expected Fit to keep the aspect ratio at 150x50, got %v", fitted.Bounds())
+	}
+
+	small := Fit(src, 1000, 1000)
+	if small.Bounds() != src.Bounds() {
+		t.Errorf("Expected Fit not to enlarge images, got %v", small.Bounds())
+	}
+}
+
+func TestBlurhashOfSolidColor(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 32, 24))
+	for y := 0; y < 24; y++ {
+		for x := 0; x < 32; x++ {
+			img.Set(x, y, color.NRGBA{R: 200, G: 100, B: 50, A: 255})
+		}
+	}
+
+	// Size flag "L" for 4x3 components, the AC maximum, the average colour, then
+	// two characters for each of the eleven AC components
+	hash := Blurhash(img, 4, 3)
+	if len(hash) != 28 || hash[0] != 'L' {
+		t.Fatalf("Expected a 28 character hash starting with L, got %s", hash)
+	}
+	if dc := encode83(200<<16+100<<8+50, 4); hash[2:6] != dc {
+		t.Errorf("Expected the average colour %s, got %s", dc, hash[2:6])
+	}
+}
@@ -0,0 +1,253 @@
+package linkpreview
+
+import (
+	"io"
+	"fmt"
+	"net"
+	"time"
+	"errors"
+	"regexp"
+	"context"
+	"strings"
+	"syscall"
+	"net/url"
+	"net/http"
+	"net/netip"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	DefaultTimeout	= time.Second * 5
+	DefaultMaxBytes	= 1 << 20
+	maxRedirects	= 5
+	maxFieldLength	= 300
+)
+
+var (
+	ErrBlockedAddress	= errors.New("address is not publicly routable")
+	ErrNotHTML		= errors.New("page is not HTML")
+	ErrNoMetadata		= errors.New("page has no preview metadata")
+)
+
+// Card is the preview shown under a chirp that links to a page
+type Card struct {
+	URL		string
+	Title		string
+	Description	string
+	ImageURL	string
+	SiteName	string
+}
+
+type Options struct {
+	Timeout		time.Duration
+	MaxBytes	int64
+	// AllowPrivateNetworks turns off the SSRF protection so tests can fetch from
+	// httptest servers on loopback. Never set it in production.
+	AllowPrivateNetworks	bool
+}
+
+// Fetcher fetches pages that users link to. Every connection, including the ones
+// made after redirects, is checked after DNS resolution so a hostname cannot point
+// the server at itself or its private network.
+type Fetcher struct {
+	client		*http.Client
+	maxBytes	int64
+}
+
+func NewFetcher(opts Options) *Fetcher {
+	if opts.Timeout == 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.MaxBytes == 0 {
+		opts.MaxBytes = DefaultMaxBytes
+	}
+
+	dialer := &net.Dialer{Timeout: opts.Timeout}
+	if !opts.AllowPrivateNetworks {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if isBlocked(addrPort.Addr()) {
+				return ErrBlockedAddress
+			}
+			return nil
+		}
+	}
+
+	transport := &http.Transport{
+		// No proxy: a proxy would make the connection on our behalf, past the check
+		Proxy:			nil,
+		DialContext:		dialer.DialContext,
+		TLSHandshakeTimeout:	opts.Timeout,
+		ResponseHeaderTimeout:	opts.Timeout,
+		MaxIdleConns:		10,
+		IdleConnTimeout:	time.Minute,
+	}
+
+	return &Fetcher{
+		client:	&http.Client{
+			Timeout:	opts.Timeout,
+			Transport:	transport,
+			CheckRedirect:	func(req *http.Request, via []*http.Request) error {
+				if len(via) >= maxRedirects {
+					return fmt.Errorf("stopped after %d redirects", maxRedirects)
+				}
+				if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+					return fmt.Errorf("redirect to unsupported scheme %q", req.URL.Scheme)
+				}
+				return nil
+			},
+		},
+		maxBytes:	opts.MaxBytes,
+	}
+}
+
+// isBlocked reports whether addr is loopback, private, link local (which includes
+// cloud metadata endpoints) or otherwise not a public unicast address
+func isBlocked(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() || addr.IsLoopback() || addr.IsLinkLocalUnicast() {
+		return true
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("2001:db8::/32"),
+}
+
+// Fetch downloads rawURL and extracts its OpenGraph or Twitter card metadata
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (Card, error) {
+	pageURL, err := url.Parse(rawURL)
+	if err != nil || (pageURL.Scheme != "http" && pageURL.Scheme != "https") || pageURL.Host == "" {
+		return Card{}, fmt.Errorf("invalid URL %q", rawURL)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL.String(), nil)
+	if err != nil {
+		return Card{}, err
+	}
+	req.Header.Set("User-Agent", "Chirpy link preview bot")
+	req.Header.Set("Accept", "text/html")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return Card{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Card{}, fmt.Errorf("page returned %s", resp.Status)
+	}
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		return Card{}, ErrNotHTML
+	}
+
+	card := Parse(io.LimitReader(resp.Body, f.maxBytes), resp.Request.URL)
+	card.URL = rawURL
+	if card.Title == "" {
+		return Card{}, ErrNoMetadata
+	}
+	return card, nil
+}
+
+// Parse reads the metadata of an HTML page. OpenGraph tags win over Twitter card
+// tags, which win over the page's <title> and meta description.
+func Parse(r io.Reader, pageURL *url.URL) Card {
+	meta := map[string]string{}
+	title := ""
+	inTitle := false
+
+	tokenizer := html.NewTokenizer(r)
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return buildCard(meta, title, pageURL)
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			switch token.Data {
+			case "meta":
+				key, content := "", ""
+				for _, attr := range token.Attr {
+					switch attr.Key {
+					case "property", "name":
+						key = strings.ToLower(attr.Val)
+					case "content":
+						content = attr.Val
+					}
+				}
+				if _, seen := meta[key]; key != "" && !seen {
+					meta[key] = content
+				}
+			case "title":
+				inTitle = title == ""
+			case "body":
+				// Metadata belongs in <head>, nothing after this is needed
+				return buildCard(meta, title, pageURL)
+			}
+		case html.TextToken:
+			if inTitle {
+				title += string(tokenizer.Text())
+			}
+		case html.EndTagToken:
+			inTitle = false
+		}
+	}
+}
+
+func buildCard(meta map[string]string, title string, pageURL *url.URL) Card {
+	first := func(values ...string) string {
+		for _, value := range values {
+			if value = strings.Join(strings.Fields(value), " "); value != "" {
+				return truncate(value, maxFieldLength)
+			}
+		}
+		return ""
+	}
+
+	card := Card{
+		Title:		first(meta["og:title"], meta["twitter:title"], title),
+		Description:	first(meta["og:description"], meta["twitter:description"], meta["description"]),
+		SiteName:	first(meta["og:site_name"]),
+	}
+
+	if image := first(meta["og:image"], meta["og:image:url"], meta["twitter:image"]); image != "" {
+		if imageURL, err := pageURL.Parse(image); err == nil && (imageURL.Scheme == "http" || imageURL.Scheme == "https") {
+			card.ImageURL = imageURL.String()
+		}
+	}
+	if card.SiteName == "" && pageURL != nil {
+		card.SiteName = pageURL.Hostname()
+	}
+	return card
+}
+
+func truncate(s string, maxLength int) string {
+	if utf8.RuneCountInString(s) <= maxLength {
+		return s
+	}
+	return string([]rune(s)[:maxLength-1]) + "…"
+}
+
+var urlPattern = regexp.MustCompile(`https?://[^\s<>"]+`)
+
+// FirstURL returns the first http or https URL in a chirp, without trailing
+// punctuation, or "" if there is none
+func FirstURL(text string) string {
+	return strings.TrimRight(urlPattern.FindString(text), ".,:;!?)'")
+}
@@ -0,0 +1,149 @@
+package linkpreview
+
+import (
+	"time"
+	"errors"
+	"context"
+	"strings"
+	"testing"
+	"net/http"
+	"net/netip"
+	"net/http/httptest"
+)
+
+const testPage = `<!DOCTYPE html>
+<html>
+<head>
+	<title>Fallback title</title>
+	<meta property="og:title" content="  Chirpy   launches ">
+	<meta name="twitter:title" content="Twitter title">
+	<meta name="description" content="A Twitter-like server">
+	<meta property="og:image" content="/images/card.png">
+</head>
+<body><meta property="og:description" content="ignored, outside head"></body>
+</html>`
+
+func TestFetchExtractsMetadata(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/old" {
+			http.Redirect(w, req, "/article", http.StatusFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(testPage))
+	}))
+	defer server.Close()
+
+	fetcher := NewFetcher(Options{AllowPrivateNetworks: true})
+	card, err := fetcher.Fetch(context.Background(), server.URL+"/old")
+	if err != nil {
+		t.Fatalf("Error fetching: %v", err)
+	}
+
+	if card.Title != "Chirpy launches" {
+		t.Errorf("Expected the OpenGraph title, got %q", card.Title)
+	}
+	if card.Description != "A Twitter-like server" {
+		t.Errorf("Expected the meta description, got %q", card.Description)
+	}
+	if card.ImageURL != server.URL+"/images/card.png" {
+		t.Errorf("Expected the image URL to be resolved against the final page, got %q", card.ImageURL)
+	}
+	if card.URL != server.URL+"/old" {
+		t.Errorf("Expected the card to keep the linked URL, got %q", card.URL)
+	}
+	if card.SiteName != "127.0.0.1" {
+		t.Errorf("Expected the host as the site name, got %q", card.SiteName)
+	}
+}
+
+func TestFetchBlocksPrivateAddresses(t *testing.T) {
+	requested := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requested = true
+	}))
+	defer server.Close()
+
+	fetcher := NewFetcher(Options{})
+	for _, target := range []string{server.URL, strings.Replace(server.URL, "127.0.0.1", "localhost", 1)} {
+		if _, err := fetcher.Fetch(context.Background(), target); !errors.Is(err, ErrBlockedAddress) {
+			t.Errorf("Expected ErrBlockedAddress for %s, got %v", target, err)
+		}
+	}
+	if requested {
+		t.Errorf("Expected no request to reach the loopback server")
+	}
+}
+
+func TestFetchLimits(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/large":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html><head>" + strings.Repeat("<!-- padding -->", 1000) + "<title>Too late</title></head></html>"))
+		case "/slow":
+			time.Sleep(time.Millisecond * 500)
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(testPage))
+		case "/json":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"title": "not a page"}`))
+		}
+	}))
+	defer server.Close()
+
+	fetcher := NewFetcher(Options{
+		Timeout:		time.Millisecond * 100,
+		MaxBytes:		1024,
+		AllowPrivateNetworks:	true,
+	})
+
+	if _, err := fetcher.Fetch(context.Background(), server.URL+"/large"); !errors.Is(err, ErrNoMetadata) {
+		t.Errorf("Expected metadata past MaxBytes to be ignored, got %v", err)
+	}
+	if _, err := fetcher.Fetch(context.Background(), server.URL+"/slow"); err == nil {
+		t.Errorf("Expected slow pages to time out")
+	}
+	if _, err := fetcher.Fetch(context.Background(), server.URL+"/json"); !errors.Is(err, ErrNotHTML) {
+		t.Errorf("Expected ErrNotHTML, got %v", err)
+	}
+	if _, err := fetcher.Fetch(context.Background(), "file:///etc/passwd"); err == nil {
+		t.Errorf("Expected file URLs to be rejected")
+	}
+}
+
+func TestIsBlocked(t *testing.T) {
+	cases := map[string]bool{
+		"127.0.0.1":		true,
+		"10.1.2.3":		true,
+		"172.16.0.1":		true,
+		"192.168.1.1":		true,
+		"169.254.169.254":	true,
+		"100.64.0.1":		true,
+		"0.0.0.0":		true,
+		"::1":			true,
+		"fd00::1":		true,
+		"fe80::1":		true,
+		"::ffff:127.0.0.1":	true,
+		"93.184.216.34":	false,
+		"2606:4700::6810:85e5":	false,
+	}
+	for address, blocked := range cases {
+		if got := isBlocked(netip.MustParseAddr(address)); got != blocked {
+			t.Errorf("isBlocked(%s) = %v, expected %v", address, got, blocked)
+		}
+	}
+}
+
+func TestFirstURL(t *testing.T) {
+	cases := map[string]string{
+		"Read this: https://example.com/post.":			"https://example.com/post",
+		"(see http://example.com/a?b=c) and https://other.com":	"http://example.com/a?b=c",
+		"no links here":						"",
+	}
+	for text, expected := range cases {
+		if got := FirstURL(text); got != expected {
+			t.Errorf("FirstURL(%q) = %q, expected %q", text, got, expected)
+		}
+	}
+}
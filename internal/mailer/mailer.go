@@ -0,0 +1,69 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"context"
+	"strings"
+	"net/smtp"
+)
+
+type Message struct {
+	To		string
+	Subject		string
+	Body		string
+}
+
+// Mailer delivers outgoing email. Handlers only depend on this interface so the
+// transport can be swapped without touching them.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// LogMailer writes messages to the server log instead of delivering them. It is
+// used when no SMTP server is configured.
+type LogMailer struct {
+	Logger	*log.Logger
+}
+
+func (m LogMailer) Send(ctx context.Context, msg Message) error {
+	logger := m.Logger
+	if logger == nil {
+		logger = log.Default()
+	}
+	logger.Printf("mail to=%s subject=%q\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+type SMTPMailer struct {
+	Addr		string
+	From		string
+	Username	string
+	Password	string
+}
+
+func (m SMTPMailer) Send(ctx context.Context, msg Message) error {
+	var smtpAuth smtp.Auth
+	if m.Username != "" {
+		host := m.Addr
+		if i := strings.LastIndex(host, ":"); i != -1 {
+			host = host[:i]
+		}
+		smtpAuth = smtp.PlainAuth("", m.Username, m.Password, host)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "From: %s\r\n", m.From)
+	fmt.Fprintf(&sb, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&sb, "Subject: %s\r\n", msg.Subject)
+	sb.WriteString("MIME-Version: 1.0\r\n")
+	sb.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	sb.WriteString(msg.Body)
+
+	err := smtp.SendMail(m.Addr, smtpAuth, m.From, []string{msg.To}, []byte(sb.String()))
+	if err != nil {
+		return fmt.Errorf("Error sending mail: %w", err)
+	}
+
+	return nil
+}
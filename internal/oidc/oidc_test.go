@@ -0,0 +1,213 @@
+package oidc
+
+import (
+	"sync"
+	"time"
+	"testing"
+	"context"
+	"net/url"
+	"net/http"
+	"math/big"
+	"crypto/rsa"
+	"crypto/rand"
+	"encoding/json"
+	"encoding/base64"
+	"net/http/httptest"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// mockIdP is a minimal in-process OpenID Connect provider. It hands out codes from
+// authorize and checks the PKCE verifier when they are exchanged.
+type mockIdP struct {
+	server		*httptest.Server
+	key		*rsa.PrivateKey
+	clientID	string
+
+	mu		sync.Mutex
+	codes		map[string]mockAuthorization
+}
+
+type mockAuthorization struct {
+	challenge	string
+	nonce		string
+	subject		string
+	email		string
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Error generating key: %v", err)
+	}
+
+	idp := &mockIdP{
+		key:		key,
+		clientID:	"chirpy-client",
+		codes:		map[string]mockAuthorization{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, req *http.Request) {
+		json.NewEncoder(w).Encode(Discovery{
+			Issuer:			idp.server.URL,
+			AuthorizationEndpoint:	idp.server.URL + "/authorize",
+			TokenEndpoint:		idp.server.URL + "/token",
+			JWKSURI:		idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, req *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"keys": []map[string]string{{
+				"kty":	"RSA",
+				"kid":	"test-key",
+				"n":	base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+				"e":	base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.PublicKey.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, req *http.Request) {
+		req.ParseForm()
+		idp.mu.Lock()
+		authz, ok := idp.codes[req.PostForm.Get("code")]
+		delete(idp.codes, req.PostForm.Get("code"))
+		idp.mu.Unlock()
+
+		if !ok || PKCEChallenge(req.PostForm.Get("code_verifier")) != authz.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]string{
+			"access_token":	"mock-access-token",
+			"token_type":	"Bearer",
+			"id_token":	idp.signIDToken(t, authz.subject, authz.email, authz.nonce, idp.clientID),
+		})
+	})
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+
+	return idp
+}
+
+// authorize plays the part of the user signing in at the provider
+func (idp *mockIdP) authorize(t *testing.T, authURL, subject, email string) string {
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("Error parsing auth URL: %v", err)
+	}
+	query := parsed.Query()
+	if query.Get("code_challenge_method") != "S256" {
+		t.Fatalf("Expected S256 PKCE, got %q", query.Get("code_challenge_method"))
+	}
+
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	code := "code-" + subject
+	idp.codes[code] = mockAuthorization{
+		challenge:	query.Get("code_challenge"),
+		nonce:		query.Get("nonce"),
+		subject:	subject,
+		email:		email,
+	}
+	return code
+}
+
+func (idp *mockIdP) signIDToken(t *testing.T, subject, email, nonce, audience string) string {
+	claims := IDTokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:		idp.server.URL,
+			Subject:	subject,
+			Audience:	jwt.ClaimStrings{audience},
+			IssuedAt:	jwt.NewNumericDate(time.Now()),
+			ExpiresAt:	jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+		Nonce:		nonce,
+		Email:		email,
+		EmailVerified:	true,
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "test-key"
+	signed, err := token.SignedString(idp.key)
+	if err != nil {
+		t.Fatalf("Error signing ID token: %v", err)
+	}
+	return signed
+}
+
+func (idp *mockIdP) provider() *Provider {
+	return NewProvider(Config{
+		Name:		"mock",
+		Issuer:		idp.server.URL,
+		ClientID:	idp.clientID,
+		ClientSecret:	"secret",
+		RedirectURL:	"http://localhost:8080/api/auth/mock/callback",
+	}, idp.server.Client())
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	idp := newMockIdP(t)
+	provider := idp.provider()
+	ctx := context.Background()
+
+	verifier, _ := RandomString()
+	authURL, err := provider.AuthCodeURL(ctx, "state", "nonce-1", verifier)
+	if err != nil {
+		t.Fatalf("Error building auth URL: %v", err)
+	}
+
+	code := idp.authorize(t, authURL, "user-1", "user@example.com")
+	claims, err := provider.Exchange(ctx, code, verifier, "nonce-1")
+	if err != nil {
+		t.Fatalf("Error exchanging code: %v", err)
+	}
+
+	if claims.Subject != "user-1" || claims.Email != "user@example.com" || !claims.IsEmailVerified() {
+		t.Errorf("Unexpected claims: %+v", claims)
+	}
+}
+
+func TestExchangeRejectsWrongVerifier(t *testing.T) {
+	idp := newMockIdP(t)
+	provider := idp.provider()
+	ctx := context.Background()
+
+	verifier, _ := RandomString()
+	authURL, _ := provider.AuthCodeURL(ctx, "state", "nonce-1", verifier)
+	code := idp.authorize(t, authURL, "user-1", "user@example.com")
+
+	if _, err := provider.Exchange(ctx, code, "not-the-verifier", "nonce-1"); err == nil {
+		t.Errorf("Expected exchange with the wrong PKCE verifier to fail")
+	}
+}
+
+func TestVerifyIDTokenRejectsBadTokens(t *testing.T) {
+	idp := newMockIdP(t)
+	provider := idp.provider()
+	ctx := context.Background()
+
+	if _, err := provider.VerifyIDToken(ctx, idp.signIDToken(t, "user-1", "a@example.com", "nonce-1", idp.clientID), "nonce-2"); err == nil {
+		t.Errorf("Expected token with the wrong nonce to fail")
+	}
+
+	if _, err := provider.VerifyIDToken(ctx, idp.signIDToken(t, "user-1", "a@example.com", "nonce-1", "someone-else"), "nonce-1"); err == nil {
+		t.Errorf("Expected token for another audience to fail")
+	}
+
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	forged := jwt.NewWithClaims(jwt.SigningMethodRS256, IDTokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:		idp.server.URL,
+			Subject:	"user-1",
+			Audience:	jwt.ClaimStrings{idp.clientID},
+			ExpiresAt:	jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+		Nonce:	"nonce-1",
+	})
+	forged.Header["kid"] = "test-key"
+	signed, _ := forged.SignedString(otherKey)
+	if _, err := provider.VerifyIDToken(ctx, signed, "nonce-1"); err == nil {
+		t.Errorf("Expected token signed by an unknown key to fail")
+	}
+}
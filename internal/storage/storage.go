@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"os"
+	"fmt"
+	"path"
+	"errors"
+	"context"
+	"strings"
+	"net/http"
+	"path/filepath"
+)
+
+var ErrInvalidKey = errors.New("invalid blob key")
+
+// BlobStore stores uploaded files such as avatars and chirp media. Keys are slash
+// separated paths like "avatars/<user id>/<random>.jpg" and are never reused, so the
+// public URLs can be cached forever.
+type BlobStore interface {
+	Put(ctx context.Context, key string, body []byte, contentType string) error
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
+
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key || strings.HasPrefix(key, "../") || key == ".." {
+		return ErrInvalidKey
+	}
+	return nil
+}
+
+// LocalStore keeps blobs in a directory on disk. Its Handler serves them, mounted
+// under the path that BaseURL points to.
+type LocalStore struct {
+	Dir	string
+	BaseURL	string
+}
+
+func NewLocalStore(dir, baseURL string) (*LocalStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("Error creating media directory: %w", err)
+	}
+	return &LocalStore{
+		Dir:		dir,
+		BaseURL:	strings.TrimSuffix(baseURL, "/"),
+	}, nil
+}
+
+func (s *LocalStore) Put(ctx context.Context, key string, body []byte, contentType string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	target := filepath.Join(s.Dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return fmt.Errorf("Error creating directory for %s: %w", key, err)
+	}
+
+	// Write to a temporary file first so a half written blob is never served
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("Error storing %s: %w", key, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(body); err != nil {
+		tmp.Close()
+		return fmt.Errorf("Error storing %s: %w", key, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("Error storing %s: %w", key, err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return fmt.Errorf("Error storing %s: %w", key, err)
+	}
+	return os.Rename(tmp.Name(), target)
+}
+
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	err := os.Remove(filepath.Join(s.Dir, filepath.FromSlash(key)))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("Error deleting %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *LocalStore) URL(key string) string {
+	return s.BaseURL + "/" + key
+}
+
+// Handler serves the stored blobs without directory listings
+func (s *LocalStore) Handler() http.Handler {
+	files := http.FileServer(http.Dir(s.Dir))
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if strings.HasSuffix(req.URL.Path, "/") || strings.Contains(req.URL.Path, "/.") {
+			http.NotFound(w, req)
+			return
+		}
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		files.ServeHTTP(w, req)
+	})
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"log"
+	"time"
+	"context"
+	"database/sql"
+
+	"github.com/kmilanbanda/chirpy/internal/database"
+	"github.com/kmilanbanda/chirpy/internal/linkpreview"
+)
+
+// linkPreviewTTL is how long a fetched preview, or a failure to fetch one, is reused
+// before the page is fetched again
+const linkPreviewTTL = time.Hour * 24
+
+type linkCard struct {
+	URL		string	`json:"url"`
+	Title		string	`json:"title"`
+	Description	string	`json:"description"`
+	ImageURL	string	`json:"image_url"`
+	SiteName	string	`json:"site_name"`
+}
+
+// fetchLinkPreview caches the preview of the first link in a chirp. It runs in the
+// background after the chirp is posted; the card shows up once it is done.
+func (cfg *apiConfig) fetchLinkPreview(body string) {
+	pageURL := linkpreview.FirstURL(body)
+	if pageURL == "" {
+		return
+	}
+
+	ctx := context.Background()
+	cached, err := cfg.db.GetLinkPreview(ctx, pageURL)
+	if err == nil && time.Since(cached.FetchedAt) < linkPreviewTTL {
+		return
+	} else if err != nil && err != sql.ErrNoRows {
+		log.Printf("Error getting link preview for %s: %v", pageURL, err)
+		return
+	}
+
+	upsertLinkPreviewParams := database.UpsertLinkPreviewParams{
+		Url:	pageURL,
+		Status:	"ready",
+	}
+	card, err := cfg.linkPreviews.Fetch(ctx, pageURL)
+	if err != nil {
+		log.Printf("Error fetching link preview for %s: %v", pageURL, err)
+		upsertLinkPreviewParams.Status = "failed"
+	} else {
+		upsertLinkPreviewParams.Title = card.Title
+		upsertLinkPreviewParams.Description = card.Description
+		upsertLinkPreviewParams.ImageUrl = card.ImageURL
+		upsertLinkPreviewParams.SiteName = card.SiteName
+	}
+
+	if err := cfg.db.UpsertLinkPreview(ctx, upsertLinkPreviewParams); err != nil {
+		log.Printf("Error saving link preview for %s: %v", pageURL, err)
+	}
+}
+
+// linkCards returns the cached cards for the first link of each chirp, keyed by URL
+func (cfg *apiConfig) linkCards(ctx context.Context, chirps []database.Chirp) (map[string]*linkCard, error) {
+	urls := []string{}
+	for _, chirp := range chirps {
+		if pageURL := linkpreview.FirstURL(chirp.Body); pageURL != "" {
+			urls = append(urls, pageURL)
+		}
+	}
+	cards := map[string]*linkCard{}
+	if len(urls) == 0 {
+		return cards, nil
+	}
+
+	previews, err := cfg.db.GetReadyLinkPreviews(ctx, urls)
+	if err != nil {
+		return nil, err
+	}
+	for _, preview := range previews {
+		cards[preview.Url] = &linkCard{
+			URL:		preview.Url,
+			Title:		preview.Title,
+			Description:	preview.Description,
+			ImageURL:	preview.ImageUrl,
+			SiteName:	preview.SiteName,
+		}
+	}
+	return cards, nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"net"
+	"time"
+	"context"
+	"strings"
+	"net/http"
+	"database/sql"
+
+	"github.com/kmilanbanda/chirpy/internal/database"
+)
+
+const (
+	accountLoginThreshold	= 5
+	ipLoginThreshold	= 20
+	loginLockoutBase	= time.Second * 30
+	loginLockoutMax		= time.Hour
+)
+
+func accountLoginKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipLoginKey(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// lockoutDuration doubles the lockout for every failure past the threshold
+func lockoutDuration(failures, threshold int32) time.Duration {
+	if failures < threshold {
+		return 0
+	}
+	duration := loginLockoutBase
+	for i := threshold; i < failures && duration < loginLockoutMax; i++ {
+		duration *= 2
+	}
+	if duration > loginLockoutMax {
+		duration = loginLockoutMax
+	}
+	return duration
+}
+
+// loginLockedUntil returns the latest lockout among keys, or the zero time if none are locked
+func (cfg *apiConfig) loginLockedUntil(ctx context.Context, keys ...string) time.Time {
+	var lockedUntil time.Time
+	for _, key := range keys {
+		failure, err := cfg.db.GetLoginFailure(ctx, key)
+		if err != nil || !failure.LockedUntil.Valid {
+			continue
+		}
+		if failure.LockedUntil.Time.After(lockedUntil) {
+			lockedUntil = failure.LockedUntil.Time
+		}
+	}
+	if time.Now().After(lockedUntil) {
+		return time.Time{}
+	}
+	return lockedUntil
+}
+
+func (cfg *apiConfig) recordLoginFailure(ctx context.Context, key string, threshold int32) error {
+	failure, err := cfg.db.RecordLoginFailure(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	duration := lockoutDuration(failure.Failures, threshold)
+	if duration == 0 {
+		return nil
+	}
+	lockLoginKeyParams := database.LockLoginKeyParams{
+		Key:		key,
+		LockedUntil:	sql.NullTime{Time: time.Now().Add(duration), Valid: true},
+	}
+	return cfg.db.LockLoginKey(ctx, lockLoginKeyParams)
+}
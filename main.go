@@ -11,21 +11,40 @@ import (
 	"database/sql"
 	"time"
 	"strconv"
+	"strings"
 
 	"github.com/kmilanbanda/chirpy/internal/auth"
 	"github.com/kmilanbanda/chirpy/internal/database"
+	"github.com/kmilanbanda/chirpy/internal/mailer"
+	"github.com/kmilanbanda/chirpy/internal/oidc"
+	"github.com/kmilanbanda/chirpy/internal/linkpreview"
+	"github.com/kmilanbanda/chirpy/internal/storage"
 	"github.com/joho/godotenv"
 	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
 	_ "github.com/lib/pq"
 )
 
 type apiConfig struct  {
 	fileserverHits 	atomic.Int32
 	db		*database.Queries
+	dbConn		*sql.DB
 	platform	string
 	maxChirpLength	int
 	secret		string
 	polkaKey	string
+	baseURL		string
+	mailer		mailer.Mailer
+	requireVerifiedEmail	bool
+	passwordPolicy	auth.PasswordPolicy
+	hasher		auth.PasswordHasher
+	dummyPasswordHash	string
+	adminKey	string
+	oidcProviders	map[string]*oidc.Provider
+	accountDeletionGracePeriod	time.Duration
+	blobStore	storage.BlobStore
+	mediaHandler	http.Handler
+	linkPreviews	*linkpreview.Fetcher
 }
 
 
@@ -63,7 +82,17 @@ func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, req *http.Request
 		return
 	}
 
-	hashedPassword, err := auth.HashPassword(reqBody.Password)
+	if err := validateEmail(reqBody.Email); err != nil {
+		handleErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := cfg.passwordPolicy.Validate(reqBody.Password, reqBody.Email); err != nil {
+		handlePasswordPolicyError(w, err)
+		return
+	}
+
+	hashedPassword, err := cfg.hasher.Hash(reqBody.Password)
 	if err != nil {
 		handleErrorResponse(w, http.StatusInternalServerError, "Error hashing password")
 		return
@@ -73,12 +102,24 @@ func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, req *http.Request
 		HashedPassword:	hashedPassword,
 	}
 
+	// New users get a random handle, which can collide with an existing one. Trying
+	// again picks another.
 	user, err := cfg.db.CreateUser(context.Background(), createUserParams)
-	if err != nil {
+	for attempt := 1; attempt < 3 && isUniqueViolation(err, usersHandleConstraint); attempt++ {
+		user, err = cfg.db.CreateUser(context.Background(), createUserParams)
+	}
+	if isUniqueViolation(err, usersEmailConstraint) {
+		handleErrorResponse(w, http.StatusConflict, "Email address is already in use")
+		return
+	} else if err != nil {
 		handleErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("Error creating user: %v", err))
 		return
 	}
 
+	if err := cfg.sendVerificationEmail(context.Background(), user); err != nil {
+		log.Printf("Error sending verification email to user %s: %v", user.ID, err)
+	}
+
 	w.WriteHeader(http.StatusCreated)
 	resp := struct{
 		ID		uuid.UUID 	`json:"id"`
@@ -86,12 +127,16 @@ func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, req *http.Request
 		UpdatedAt	time.Time	`json:"updated_at"`
 		Email		string		`json:"email"`
 		IsChirpyRed	bool		`json:"is_chirpy_red"`
+		IsEmailVerified	bool		`json:"is_email_verified"`
+		Handle		string		`json:"handle"`
 	}{
 		ID:		user.ID,
 		CreatedAt:	user.CreatedAt,
 		UpdatedAt:	user.UpdatedAt,
 		Email:		user.Email,
 		IsChirpyRed:	user.IsChirpyRed,
+		IsEmailVerified:	user.EmailVerifiedAt.Valid,
+		Handle:		user.Handle,
 	}
 	dat, _  := json.Marshal(resp)
 	w.Write(dat)
@@ -137,6 +182,56 @@ func loadConfig() (*apiConfig, error) {
 		return nil, fmt.Errorf("POLKA_KEY must be set")
 	}
 
+	baseURL := os.Getenv("BASE_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:8080"
+	}
+
+	var mail mailer.Mailer = mailer.LogMailer{}
+	if smtpAddr := os.Getenv("SMTP_ADDR"); smtpAddr != "" {
+		mail = mailer.SMTPMailer{
+			Addr:		smtpAddr,
+			From:		os.Getenv("SMTP_FROM"),
+			Username:	os.Getenv("SMTP_USERNAME"),
+			Password:	os.Getenv("SMTP_PASSWORD"),
+		}
+	}
+
+	requireVerifiedEmail := os.Getenv("REQUIRE_VERIFIED_EMAIL") == "true"
+
+	passwordPolicy, err := loadPasswordPolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	hasher, err := loadPasswordHasher()
+	if err != nil {
+		return nil, err
+	}
+
+	oidcProviders, err := loadOIDCProviders(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, err
+	}
+
+	accountDeletionGracePeriod := time.Hour * 24 * 30
+	if envGracePeriod := os.Getenv("ACCOUNT_DELETION_GRACE_PERIOD"); envGracePeriod != "" {
+		accountDeletionGracePeriod, err = time.ParseDuration(envGracePeriod)
+		if err != nil {
+			return nil, fmt.Errorf("ACCOUNT_DELETION_GRACE_PERIOD must be a duration like 720h: %v", err)
+		}
+	}
+
+	blobStore, mediaHandler, err := loadBlobStore(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, err
+	}
+
+	dummyPasswordHash, err := hasher.Hash(uuid.NewString())
+	if err != nil {
+		return nil, err
+	}
+
 	db, err := sql.Open("postgres", dbURL)
 	if err != nil {
 		return nil, fmt.Errorf("Fatal error occured during connection to database: %v", err)
@@ -146,30 +241,249 @@ func loadConfig() (*apiConfig, error) {
 	return &apiConfig{
 		fileserverHits: atomic.Int32{},
 		db:		dbQueries,
+		dbConn:		db,
 		platform:	envPlatform,
 		maxChirpLength: envMaxChirpLength,
 		secret:		secret,
 		polkaKey:	polkaKey,
+		baseURL:	strings.TrimSuffix(baseURL, "/"),
+		mailer:		mail,
+		requireVerifiedEmail:	requireVerifiedEmail,
+		passwordPolicy:	passwordPolicy,
+		hasher:		hasher,
+		dummyPasswordHash:	dummyPasswordHash,
+		adminKey:	os.Getenv("ADMIN_KEY"),
+		oidcProviders:	oidcProviders,
+		accountDeletionGracePeriod:	accountDeletionGracePeriod,
+		blobStore:	blobStore,
+		mediaHandler:	mediaHandler,
+		linkPreviews:	linkpreview.NewFetcher(linkpreview.Options{}),
 	}, nil 
 }
 
+func loadPasswordPolicy() (auth.PasswordPolicy, error) {
+	policy := auth.PasswordPolicy{
+		MinLength:	8,
+		MinEntropyBits:	36,
+		DisallowEmail:	os.Getenv("PASSWORD_ALLOW_EMAIL") != "true",
+	}
+
+	if envMinLength := os.Getenv("PASSWORD_MIN_LENGTH"); envMinLength != "" {
+		minLength, err := strconv.Atoi(envMinLength)
+		if err != nil {
+			return policy, fmt.Errorf("PASSWORD_MIN_LENGTH must be a number: %v", err)
+		}
+		policy.MinLength = minLength
+	}
+
+	if envMinEntropy := os.Getenv("PASSWORD_MIN_ENTROPY_BITS"); envMinEntropy != "" {
+		minEntropy, err := strconv.ParseFloat(envMinEntropy, 64)
+		if err != nil {
+			return policy, fmt.Errorf("PASSWORD_MIN_ENTROPY_BITS must be a number: %v", err)
+		}
+		policy.MinEntropyBits = minEntropy
+	}
+
+	if breachedFile := os.Getenv("BREACHED_PASSWORDS_FILE"); breachedFile != "" {
+		breached, err := auth.LoadBreachedPasswordFile(breachedFile)
+		if err != nil {
+			return policy, err
+		}
+		policy.Breached = breached
+	}
+
+	return policy, nil
+}
+
+func loadPasswordHasher() (auth.PasswordHasher, error) {
+	hasher := auth.DefaultHasher
+	hasher.Algorithm = auth.AlgorithmArgon2id
+
+	if algorithm := os.Getenv("PASSWORD_HASH_ALGORITHM"); algorithm != "" {
+		if algorithm != auth.AlgorithmArgon2id && algorithm != auth.AlgorithmBcrypt {
+			return hasher, fmt.Errorf("PASSWORD_HASH_ALGORITHM must be %q or %q", auth.AlgorithmArgon2id, auth.AlgorithmBcrypt)
+		}
+		hasher.Algorithm = algorithm
+	}
+
+	if envBcryptCost := os.Getenv("BCRYPT_COST"); envBcryptCost != "" {
+		bcryptCost, err := strconv.Atoi(envBcryptCost)
+		if err != nil {
+			return hasher, fmt.Errorf("BCRYPT_COST must be a number: %v", err)
+		}
+		// bcrypt would quietly hash with its default cost instead, and every login
+		// would then look like it needs a rehash
+		if bcryptCost < bcrypt.MinCost || bcryptCost > bcrypt.MaxCost {
+			return hasher, fmt.Errorf("BCRYPT_COST must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+		hasher.BcryptCost = bcryptCost
+	}
+
+	if envMemory := os.Getenv("ARGON2_MEMORY_KIB"); envMemory != "" {
+		memory, err := strconv.ParseUint(envMemory, 10, 32)
+		if err != nil {
+			return hasher, fmt.Errorf("ARGON2_MEMORY_KIB must be a number: %v", err)
+		}
+		hasher.Argon2.Memory = uint32(memory)
+	}
+
+	if envIterations := os.Getenv("ARGON2_ITERATIONS"); envIterations != "" {
+		iterations, err := strconv.ParseUint(envIterations, 10, 32)
+		if err != nil {
+			return hasher, fmt.Errorf("ARGON2_ITERATIONS must be a number: %v", err)
+		}
+		hasher.Argon2.Iterations = uint32(iterations)
+	}
+
+	return hasher, nil
+}
+
+// loadOIDCProviders reads OIDC_PROVIDERS, a comma separated list of names, and the
+// OIDC_<NAME>_ISSUER, OIDC_<NAME>_CLIENT_ID and OIDC_<NAME>_CLIENT_SECRET for each
+func loadOIDCProviders(baseURL string) (map[string]*oidc.Provider, error) {
+	providers := map[string]*oidc.Provider{}
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		config := oidc.Config{
+			Name:		name,
+			Issuer:		os.Getenv(prefix + "ISSUER"),
+			ClientID:	os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret:	os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:	baseURL + "/api/auth/" + name + "/callback",
+		}
+		if config.Issuer == "" || config.ClientID == "" {
+			return nil, fmt.Errorf("%sISSUER and %sCLIENT_ID must be set", prefix, prefix)
+		}
+		providers[name] = oidc.NewProvider(config, nil)
+	}
+
+	return providers, nil
+}
+
+// loadBlobStore picks where uploads are stored from STORAGE_BACKEND. The local store
+// also returns the handler that serves its files under /media/; S3 serves its own.
+func loadBlobStore(baseURL string) (storage.BlobStore, http.Handler, error) {
+	switch backend := os.Getenv("STORAGE_BACKEND"); backend {
+	case "", "local":
+		mediaDir := os.Getenv("MEDIA_DIR")
+		if mediaDir == "" {
+			mediaDir = "./media"
+		}
+		store, err := storage.NewLocalStore(mediaDir, baseURL+"/media")
+		if err != nil {
+			return nil, nil, err
+		}
+		return store, store.Handler(), nil
+	case "s3":
+		config := storage.S3Config{
+			Endpoint:		os.Getenv("S3_ENDPOINT"),
+			Region:			os.Getenv("S3_REGION"),
+			Bucket:			os.Getenv("S3_BUCKET"),
+			AccessKeyID:		os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey:	os.Getenv("S3_SECRET_ACCESS_KEY"),
+			PublicURL:		os.Getenv("S3_PUBLIC_URL"),
+		}
+		if config.Endpoint == "" || config.Bucket == "" || config.AccessKeyID == "" || config.SecretAccessKey == "" {
+			return nil, nil, fmt.Errorf("S3_ENDPOINT, S3_BUCKET, S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY must be set")
+		}
+		return storage.NewS3Store(config, nil), nil, nil
+	default:
+		return nil, nil, fmt.Errorf("STORAGE_BACKEND must be \"local\" or \"s3\", got %q", backend)
+	}
+}
+
 func (cfg *apiConfig) setupEndpoints(serveMux *http.ServeMux) {
 	const filepathRoot = "."
 	fileHandler := http.FileServer(http.Dir(filepathRoot))
 	serveMux.HandleFunc("GET /api/healthz", handlerFunc)
 	serveMux.Handle("/app/", http.StripPrefix("/app", cfg.middlewareMetricsInc(fileHandler)))
+	if cfg.mediaHandler != nil {
+		serveMux.Handle("GET /media/", http.StripPrefix("/media", cfg.mediaHandler))
+	}
 	serveMux.HandleFunc("GET /admin/metrics", cfg.handlerHits)
 	serveMux.HandleFunc("POST /api/users", cfg.handlerCreateUser)
 	serveMux.HandleFunc("POST /api/login", cfg.handlerLogin)
+	serveMux.HandleFunc("POST /api/login/mfa", cfg.handlerLoginMFA)
+	serveMux.HandleFunc("GET /api/auth/{provider}/login", cfg.handlerOIDCLogin)
+	serveMux.HandleFunc("GET /api/auth/{provider}/callback", cfg.handlerOIDCCallback)
 	serveMux.HandleFunc("POST /admin/reset", cfg.handlerReset)
+	serveMux.HandleFunc("POST /admin/login/unlock", cfg.handlerUnlockLogin)
 	serveMux.HandleFunc("POST /api/chirps", cfg.handlerPostChirp)
 	serveMux.HandleFunc("GET /api/chirps", cfg.handlerGetChirps)
 	serveMux.HandleFunc("GET /api/chirps/{chirpID}", cfg.handlerGetChirp)
+	serveMux.HandleFunc("GET /api/chirps/scheduled", cfg.handlerGetScheduledChirps)
 	serveMux.HandleFunc("POST /api/refresh", cfg.handlerRefresh)
 	serveMux.HandleFunc("POST /api/revoke", cfg.handlerRevoke)
 	serveMux.HandleFunc("PUT /api/users", cfg.handlerUpdateUser)
+	serveMux.HandleFunc("PATCH /api/users/me", cfg.handlerPatchUser)
+	serveMux.HandleFunc("DELETE /api/users/me", cfg.handlerDeleteUser)
+	serveMux.HandleFunc("POST /api/users/me/export", cfg.handlerCreateDataExport)
+	serveMux.HandleFunc("GET /api/users/me/export/{exportID}", cfg.handlerGetDataExport)
+	serveMux.HandleFunc("GET /api/exports/download", cfg.handlerDownloadDataExport)
+	serveMux.HandleFunc("POST /api/users/me/import", cfg.handlerCreateImportJob)
+	serveMux.HandleFunc("PUT /api/users/me/avatar", cfg.handlerPutAvatar)
+	serveMux.HandleFunc("DELETE /api/users/me/avatar", cfg.handlerDeleteAvatar)
+	serveMux.HandleFunc("PUT /api/users/me/header", cfg.handlerPutHeader)
+	serveMux.HandleFunc("DELETE /api/users/me/header", cfg.handlerDeleteHeader)
+	serveMux.HandleFunc("GET /api/users/{handleOrID}", cfg.handlerGetProfile)
+	serveMux.HandleFunc("POST /api/users/{handleOrID}/follow", cfg.handlerFollowUser)
+	serveMux.HandleFunc("DELETE /api/users/{handleOrID}/follow", cfg.handlerUnfollowUser)
+	serveMux.HandleFunc("POST /api/users/{handleOrID}/block", cfg.handlerBlockUser)
+	serveMux.HandleFunc("DELETE /api/users/{handleOrID}/block", cfg.handlerUnblockUser)
+	serveMux.HandleFunc("POST /api/users/{handleOrID}/mute", cfg.handlerMuteUser)
+	serveMux.HandleFunc("DELETE /api/users/{handleOrID}/mute", cfg.handlerUnmuteUser)
+	serveMux.HandleFunc("GET /api/users/me/blocks", cfg.handlerGetBlocks)
+	serveMux.HandleFunc("GET /api/users/me/mutes", cfg.handlerGetMutes)
+	serveMux.HandleFunc("PUT /api/users/me/pinned_chirp", cfg.handlerPinChirp)
+	serveMux.HandleFunc("DELETE /api/users/me/pinned_chirp", cfg.handlerUnpinChirp)
+	serveMux.HandleFunc("POST /api/users/me/muted_keywords", cfg.handlerCreateMutedKeyword)
+	serveMux.HandleFunc("GET /api/users/me/muted_keywords", cfg.handlerGetMutedKeywords)
+	serveMux.HandleFunc("DELETE /api/users/me/muted_keywords/{keywordID}", cfg.handlerDeleteMutedKeyword)
+	serveMux.HandleFunc("GET /api/users/me/follow_requests", cfg.handlerGetFollowRequests)
+	serveMux.HandleFunc("POST /api/users/me/follow_requests/{handleOrID}", cfg.handlerApproveFollowRequest)
+	serveMux.HandleFunc("DELETE /api/users/me/follow_requests/{handleOrID}", cfg.handlerRejectFollowRequest)
+	serveMux.HandleFunc("GET /api/users/me/import/{jobID}", cfg.handlerGetImportJob)
+	serveMux.HandleFunc("POST /api/users/me/totp", cfg.handlerEnrollTOTP)
+	serveMux.HandleFunc("POST /api/users/me/totp/confirm", cfg.handlerConfirmTOTP)
+	serveMux.HandleFunc("DELETE /api/users/me/totp", cfg.handlerDisableTOTP)
 	serveMux.HandleFunc("DELETE /api/chirps/{chirpID}", cfg.handlerDeleteChirp)
+	serveMux.HandleFunc("POST /api/chirps/{chirpID}/poll/votes", cfg.handlerVotePoll)
+	serveMux.HandleFunc("POST /api/chirps/{chirpID}/bookmark", cfg.handlerCreateBookmark)
+	serveMux.HandleFunc("DELETE /api/chirps/{chirpID}/bookmark", cfg.handlerDeleteBookmark)
+	serveMux.HandleFunc("GET /api/bookmarks", cfg.handlerGetBookmarks)
+	serveMux.HandleFunc("POST /api/lists", cfg.handlerCreateList)
+	serveMux.HandleFunc("GET /api/lists", cfg.handlerGetLists)
+	serveMux.HandleFunc("GET /api/lists/{listID}", cfg.handlerGetList)
+	serveMux.HandleFunc("PATCH /api/lists/{listID}", cfg.handlerUpdateList)
+	serveMux.HandleFunc("DELETE /api/lists/{listID}", cfg.handlerDeleteList)
+	serveMux.HandleFunc("GET /api/lists/{listID}/members", cfg.handlerGetListMembers)
+	serveMux.HandleFunc("PUT /api/lists/{listID}/members/{handleOrID}", cfg.handlerAddListMember)
+	serveMux.HandleFunc("DELETE /api/lists/{listID}/members/{handleOrID}", cfg.handlerRemoveListMember)
+	serveMux.HandleFunc("GET /api/lists/{listID}/chirps", cfg.handlerGetListChirps)
+	serveMux.HandleFunc("POST /api/drafts", cfg.handlerCreateDraft)
+	serveMux.HandleFunc("GET /api/drafts", cfg.handlerGetDrafts)
+	serveMux.HandleFunc("GET /api/drafts/{draftID}", cfg.handlerGetDraft)
+	serveMux.HandleFunc("PUT /api/drafts/{draftID}", cfg.handlerUpdateDraft)
+	serveMux.HandleFunc("DELETE /api/drafts/{draftID}", cfg.handlerDeleteDraft)
+	serveMux.HandleFunc("POST /api/drafts/{draftID}/publish", cfg.handlerPublishDraft)
+	serveMux.HandleFunc("POST /api/media", cfg.handlerUploadMedia)
+	serveMux.HandleFunc("PATCH /api/media/{mediaID}", cfg.handlerUpdateMedia)
 	serveMux.HandleFunc("POST /api/polka/webhooks", cfg.handlerUpgradeUser)
+	serveMux.HandleFunc("POST /api/oauth/clients", cfg.handlerCreateOAuthClient)
+	serveMux.HandleFunc("GET /api/oauth/clients", cfg.handlerGetOAuthClients)
+	serveMux.HandleFunc("DELETE /api/oauth/clients/{clientID}", cfg.handlerDeleteOAuthClient)
+	serveMux.HandleFunc("GET /oauth/authorize", cfg.handlerOAuthAuthorize)
+	serveMux.HandleFunc("POST /oauth/authorize", cfg.handlerOAuthApprove)
+	serveMux.HandleFunc("POST /oauth/token", cfg.handlerOAuthToken)
+	serveMux.HandleFunc("POST /oauth/revoke", cfg.handlerOAuthRevoke)
+	serveMux.HandleFunc("POST /oauth/introspect", cfg.handlerOAuthIntrospect)
+	serveMux.HandleFunc("GET /api/users/verify", cfg.handlerVerifyEmail)
+	serveMux.HandleFunc("POST /api/users/verify/resend", cfg.handlerResendVerification)
 }
 
 func main() {
@@ -185,11 +499,14 @@ func main() {
 	serveMux := http.NewServeMux()
 	server := &http.Server{
 		Addr:		":" + port,
-		Handler: 	serveMux,
+		Handler: 	cfg.middlewarePendingDeletion(serveMux),
 	}
 
 		
 	cfg.setupEndpoints(serveMux)
+	go cfg.purgeDeletedAccounts(context.Background(), accountDeletionPurgeInterval)
+	go cfg.purgeUnattachedMedia(context.Background(), mediaPurgeInterval)
+	go cfg.publishScheduledChirps(context.Background(), chirpSchedulerInterval)
 	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		log.Fatalf("Server errror: %v", err)
 	}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"time"
+	"strconv"
+	"strings"
+	"net/url"
+	"encoding/base64"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageSize	= 20
+	maxPageSize	= 100
+)
+
+// pageCursor marks where a page ended in a listing ordered newest first by time and
+// then ID. Keyset cursors stay correct when rows are added or removed between pages,
+// unlike offsets.
+type pageCursor struct {
+	CreatedAt	time.Time
+	ID		uuid.UUID
+}
+
+// firstPage sorts after every row, so the first page needs no special case in SQL
+var firstPage = pageCursor{
+	CreatedAt:	time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC),
+	ID:		uuid.Max,
+}
+
+// encode returns the cursor as an opaque URL-safe string for "next_cursor"
+func (cursor pageCursor) encode() string {
+	raw := cursor.CreatedAt.UTC().Format(time.RFC3339Nano) + " " + cursor.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodePageCursor(encoded string) (pageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return pageCursor{}, err
+	}
+	createdAtString, idString, found := strings.Cut(string(raw), " ")
+	if !found {
+		return pageCursor{}, fmt.Errorf("malformed cursor")
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, createdAtString)
+	if err != nil {
+		return pageCursor{}, err
+	}
+	id, err := uuid.Parse(idString)
+	if err != nil {
+		return pageCursor{}, err
+	}
+	return pageCursor{CreatedAt: createdAt, ID: id}, nil
+}
+
+type pageRequest struct {
+	Limit	int32
+	Before	pageCursor
+}
+
+// parsePageRequest reads the "limit" and "cursor" query parameters
+func parsePageRequest(query url.Values) (pageRequest, error) {
+	page := pageRequest{
+		Limit:	defaultPageSize,
+		Before:	firstPage,
+	}
+	if limitString := query.Get("limit"); limitString != "" {
+		limit, err := strconv.Atoi(limitString)
+		if err != nil || limit < 1 || limit > maxPageSize {
+			return pageRequest{}, fmt.Errorf("Limit must be between 1 and %d", maxPageSize)
+		}
+		page.Limit = int32(limit)
+	}
+	if cursorString := query.Get("cursor"); cursorString != "" {
+		cursor, err := decodePageCursor(cursorString)
+		if err != nil {
+			return pageRequest{}, fmt.Errorf("Invalid cursor")
+		}
+		page.Before = cursor
+	}
+	return page, nil
+}
+
+// fetchLimit is one more than the page size, so a query can tell whether another page follows
+func (page pageRequest) fetchLimit() int32 {
+	return page.Limit + 1
+}
+
+// chirpPage is a page of a chirp listing. NextCursor is null on the last page.
+type chirpPage struct {
+	Chirps		[]chirpResponse	`json:"chirps"`
+	NextCursor	*string		`json:"next_cursor"`
+}
+
+// newChirpPage trims the extra row fetched by fetchLimit. cursors holds the position
+// of each chirp in the listing's own order.
+func newChirpPage(page pageRequest, chirps []chirpResponse, cursors []pageCursor) chirpPage {
+	resp := chirpPage{Chirps: chirps}
+	if len(chirps) > int(page.Limit) {
+		resp.Chirps = chirps[:page.Limit]
+		next := cursors[page.Limit-1].encode()
+		resp.NextCursor = &next
+	}
+	if resp.Chirps == nil {
+		resp.Chirps = []chirpResponse{}
+	}
+	return resp
+}
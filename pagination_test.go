@@ -0,0 +1,119 @@
+package main
+
+import (
+	"time"
+	"testing"
+	"net/url"
+	"encoding/base64"
+
+	"github.com/google/uuid"
+)
+
+func TestPageCursorRoundTrip(t *testing.T) {
+	cursor := pageCursor{
+		CreatedAt:	time.Date(2024, time.March, 1, 12, 30, 0, 123456789, time.UTC),
+		ID:		uuid.New(),
+	}
+	decoded, err := decodePageCursor(cursor.encode())
+	if err != nil {
+		t.Fatalf("Error decoding cursor: %v", err)
+	}
+	if !decoded.CreatedAt.Equal(cursor.CreatedAt) || decoded.ID != cursor.ID {
+		t.Errorf("Expected %+v, got %+v", cursor, decoded)
+	}
+}
+
+func TestDecodePageCursorRejectsMalformed(t *testing.T) {
+	tests := []struct {
+		name	string
+		encoded	string
+	}{
+		{"not base64", "!!!"},
+		{"no separator", encodeRawCursor("2024-03-01T12:30:00Z")},
+		{"bad time", encodeRawCursor("yesterday " + uuid.NewString())},
+		{"bad id", encodeRawCursor("2024-03-01T12:30:00Z not-a-uuid")},
+	}
+	for _, test := range tests {
+		if _, err := decodePageCursor(test.encoded); err == nil {
+			t.Errorf("Expected an error decoding a cursor with %s", test.name)
+		}
+	}
+}
+
+func encodeRawCursor(raw string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func TestParsePageRequest(t *testing.T) {
+	cursor := pageCursor{CreatedAt: time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC), ID: uuid.New()}
+	tests := []struct {
+		query		string
+		wantLimit	int32
+		wantBefore	pageCursor
+		wantErr		bool
+	}{
+		{"", defaultPageSize, firstPage, false},
+		{"limit=1", 1, firstPage, false},
+		{"limit=100", maxPageSize, firstPage, false},
+		{"limit=5&cursor=" + cursor.encode(), 5, cursor, false},
+		{"limit=0", 0, pageCursor{}, true},
+		{"limit=101", 0, pageCursor{}, true},
+		{"limit=ten", 0, pageCursor{}, true},
+		{"cursor=garbage", 0, pageCursor{}, true},
+	}
+	for _, test := range tests {
+		query, _ := url.ParseQuery(test.query)
+		page, err := parsePageRequest(query)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("parsePageRequest(%q): expected an error", test.query)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parsePageRequest(%q): %v", test.query, err)
+			continue
+		}
+		if page.Limit != test.wantLimit || !page.Before.CreatedAt.Equal(test.wantBefore.CreatedAt) || page.Before.ID != test.wantBefore.ID {
+			t.Errorf("parsePageRequest(%q) = %+v, want limit %d before %+v", test.query, page, test.wantLimit, test.wantBefore)
+		}
+	}
+}
+
+func TestNewChirpPage(t *testing.T) {
+	chirps := make([]chirpResponse, 3)
+	cursors := make([]pageCursor, 3)
+	for i := range chirps {
+		chirps[i] = chirpResponse{ID: uuid.New()}
+		cursors[i] = pageCursor{CreatedAt: time.Date(2024, time.March, 3-i, 0, 0, 0, 0, time.UTC), ID: chirps[i].ID}
+	}
+
+	tests := []struct {
+		name		string
+		limit		int32
+		fetched		int
+		wantChirps	int
+		wantNext	*pageCursor
+	}{
+		{"empty", 2, 0, 0, nil},
+		{"short page", 2, 1, 1, nil},
+		{"exactly full", 3, 3, 3, nil},
+		{"more to come", 2, 3, 2, &cursors[1]},
+	}
+	for _, test := range tests {
+		page := pageRequest{Limit: test.limit, Before: firstPage}
+		got := newChirpPage(page, chirps[:test.fetched], cursors[:test.fetched])
+		if got.Chirps == nil || len(got.Chirps) != test.wantChirps {
+			t.Errorf("%s: expected %d chirps, got %v", test.name, test.wantChirps, got.Chirps)
+		}
+		if test.wantNext == nil {
+			if got.NextCursor != nil {
+				t.Errorf("%s: expected no next cursor, got %q", test.name, *got.NextCursor)
+			}
+			continue
+		}
+		if got.NextCursor == nil || *got.NextCursor != test.wantNext.encode() {
+			t.Errorf("%s: expected the next cursor to point at the last chirp on the page", test.name)
+		}
+	}
+}
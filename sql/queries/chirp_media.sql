@@ -0,0 +1,39 @@
+-- name: CreateChirpMedia :one
+INSERT INTO chirp_media (id, created_at, user_id, content_type, blob_key, thumbnail_key, width, height, alt_text, blurhash)
+VALUES (
+	gen_random_uuid(),
+	NOW(),
+	$1,
+	$2,
+	$3,
+	$4,
+	$5,
+	$6,
+	$7,
+	$8
+)
+RETURNING *;
+
+-- name: GetChirpMedia :one
+SELECT * FROM chirp_media WHERE id = $1;
+
+-- name: UpdateChirpMediaAltText :one
+UPDATE chirp_media SET alt_text = $2 WHERE id = $1 RETURNING *;
+
+-- name: AttachChirpMedia :execrows
+UPDATE chirp_media SET chirp_id = $2, position = $3
+WHERE id = $1 AND user_id = $4 AND chirp_id IS NULL;
+
+-- name: GetMediaForChirps :many
+SELECT * FROM chirp_media WHERE chirp_id = ANY(@chirp_ids::uuid[]) ORDER BY position;
+
+-- name: GetMediaForUsersScheduledForDeletion :many
+SELECT chirp_media.* FROM chirp_media
+JOIN users ON users.id = chirp_media.user_id
+WHERE users.deletion_scheduled_for <= NOW();
+
+-- name: DeleteUnattachedChirpMedia :many
+DELETE FROM chirp_media
+WHERE chirp_id IS NULL AND created_at < $1
+	AND NOT EXISTS (SELECT 1 FROM drafts WHERE chirp_media.id = ANY(drafts.media_ids))
+RETURNING *;
@@ -1,25 +1,94 @@
 -- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, visibility, content_warning, body_words, content_warning_words, published_at)
 VALUES (
 	gen_random_uuid(),
 	NOW(),
 	NOW(),
 	$1,
-	$2
+	$2,
+	$3,
+	$4,
+	$5,
+	$6,
+	NOW()
+)
+RETURNING *;
+
+-- name: CreateScheduledChirp :one
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, visibility, content_warning, body_words, content_warning_words, publish_at)
+VALUES (
+	gen_random_uuid(),
+	NOW(),
+	NOW(),
+	$1,
+	$2,
+	$3,
+	$4,
+	$5,
+	$6,
+	$7
 )
 RETURNING *;
 
 -- name: GetChirps :many
-SELECT * FROM chirps ORDER BY created_at;
+SELECT * FROM chirps
+WHERE published_at IS NOT NULL
+	AND (visibility <> 'unlisted' OR user_id = @viewer_id)
+	AND chirp_visible_to(id, user_id, visibility, published_at, @viewer_id::uuid)
+	AND NOT chirp_muted_for(body_words, content_warning_words, user_id, @viewer_id::uuid)
+	AND NOT EXISTS (
+		SELECT 1 FROM mutes WHERE muter_id = @viewer_id AND muted_id = chirps.user_id
+	)
+	AND NOT EXISTS (
+		SELECT 1 FROM blocks WHERE blocker_id = @viewer_id AND blocked_id = chirps.user_id
+	)
+ORDER BY created_at;
 
 -- name: ResetChirps :exec
 SELECT FROM chirps;
 
 -- name: GetChirpsByUser :many
-SELECT * FROM chirps WHERE user_id = $1 ORDER BY created_at;
+SELECT * FROM chirps
+WHERE user_id = @user_id
+	AND published_at IS NOT NULL
+	AND chirp_visible_to(id, user_id, visibility, published_at, @viewer_id::uuid)
+	AND NOT chirp_muted_for(body_words, content_warning_words, user_id, @viewer_id::uuid)
+ORDER BY created_at;
 
 -- name: GetChirp :one
-SELECT * FROM chirps WHERE id = $1;
+SELECT * FROM chirps
+WHERE id = @id
+	AND chirp_visible_to(id, user_id, visibility, published_at, @viewer_id::uuid);
 
 -- name: DeleteChirp :exec
 DELETE FROM chirps WHERE id = $1;
+
+-- name: ImportChirp :one
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, visibility, body_words, content_warning, content_warning_words, published_at)
+SELECT gen_random_uuid(), @created_at::timestamp, @created_at::timestamp, @body::text, @user_id::uuid, @visibility::text, @body_words::text, @content_warning::text, @content_warning_words::text, @created_at::timestamp
+WHERE NOT EXISTS (
+	SELECT 1 FROM chirps WHERE user_id = @user_id AND body = @body AND created_at = @created_at
+)
+RETURNING *;
+
+-- name: GetScheduledChirpsByUser :many
+SELECT * FROM chirps WHERE user_id = $1 AND published_at IS NULL ORDER BY publish_at;
+
+-- name: PublishDueChirps :many
+UPDATE chirps SET created_at = NOW(), updated_at = NOW(), published_at = NOW()
+WHERE published_at IS NULL AND id IN (
+	SELECT id FROM chirps
+	WHERE published_at IS NULL AND publish_at <= NOW()
+	ORDER BY publish_at
+	LIMIT 100
+	FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: CreateChirpMention :exec
+INSERT INTO chirp_mentions (chirp_id, user_id)
+VALUES (
+	$1,
+	$2
+)
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,33 @@
+-- name: CreateDataExport :one
+INSERT INTO data_exports (id, created_at, updated_at, user_id, status)
+VALUES (
+	gen_random_uuid(),
+	NOW(),
+	NOW(),
+	$1,
+	'pending'
+)
+RETURNING *;
+
+-- name: GetDataExport :one
+SELECT * FROM data_exports WHERE id = $1;
+
+-- name: GetPendingDataExportByUser :one
+SELECT * FROM data_exports
+WHERE user_id = $1 AND status = 'pending' AND created_at > NOW() - INTERVAL '1 hour'
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: CompleteDataExport :exec
+UPDATE data_exports
+SET status = 'ready', archive = $2, download_token = $3, expires_at = $4, updated_at = NOW()
+WHERE id = $1;
+
+-- name: FailDataExport :exec
+UPDATE data_exports SET status = 'failed', updated_at = NOW() WHERE id = $1;
+
+-- name: GetDataExportByDownloadToken :one
+SELECT * FROM data_exports WHERE download_token = $1 AND status = 'ready';
+
+-- name: DeleteExpiredDataExports :exec
+DELETE FROM data_exports WHERE expires_at < NOW();
@@ -0,0 +1,41 @@
+-- name: CreateDraft :one
+INSERT INTO drafts (id, created_at, updated_at, user_id, body, media_ids, poll_options, poll_closes_at, publish_at, visibility, content_warning)
+VALUES (
+	gen_random_uuid(),
+	NOW(),
+	NOW(),
+	$1,
+	$2,
+	$3,
+	$4,
+	$5,
+	$6,
+	$7,
+	$8
+)
+RETURNING *;
+
+-- name: GetDraft :one
+SELECT * FROM drafts WHERE id = $1;
+
+-- name: GetDraftsByUser :many
+SELECT * FROM drafts WHERE user_id = $1 ORDER BY updated_at DESC;
+
+-- name: CountDraftsByUser :one
+SELECT COUNT(*) FROM drafts WHERE user_id = $1;
+
+-- name: UpdateDraft :one
+UPDATE drafts
+SET body = $2,
+	media_ids = $3,
+	poll_options = $4,
+	poll_closes_at = $5,
+	publish_at = $6,
+	visibility = $7,
+	content_warning = $8,
+	updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteDraft :exec
+DELETE FROM drafts WHERE id = $1;
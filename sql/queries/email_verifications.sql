@@ -0,0 +1,17 @@
+-- name: CreateEmailVerification :one
+INSERT INTO email_verifications (token, created_at, user_id, email, expires_at, used_at)
+VALUES (
+	$1,
+	NOW(),
+	$2,
+	$3,
+	$4,
+	NULL
+)
+RETURNING *;
+
+-- name: GetEmailVerification :one
+SELECT * FROM email_verifications WHERE token = $1;
+
+-- name: UseEmailVerification :exec
+UPDATE email_verifications SET used_at = NOW() WHERE token = $1;
@@ -0,0 +1,52 @@
+-- name: CreateFollow :exec
+INSERT INTO follows (follower_id, followee_id, created_at, status)
+VALUES (
+	$1,
+	$2,
+	NOW(),
+	$3
+)
+ON CONFLICT DO NOTHING;
+
+-- name: GetFollow :one
+SELECT * FROM follows WHERE follower_id = $1 AND followee_id = $2;
+
+-- name: DeleteFollow :exec
+DELETE FROM follows WHERE follower_id = $1 AND followee_id = $2;
+
+-- name: GetFollowing :many
+SELECT users.id, users.handle, follows.created_at
+FROM follows
+JOIN users ON users.id = follows.followee_id
+WHERE follows.follower_id = $1 AND follows.status = 'approved'
+ORDER BY follows.created_at;
+
+-- name: GetFollowers :many
+SELECT users.id, users.handle, follows.created_at
+FROM follows
+JOIN users ON users.id = follows.follower_id
+WHERE follows.followee_id = $1 AND follows.status = 'approved'
+ORDER BY follows.created_at;
+
+-- name: GetFollowRequests :many
+SELECT users.id, users.handle, follows.created_at
+FROM follows
+JOIN users ON users.id = follows.follower_id
+WHERE follows.followee_id = $1 AND follows.status = 'pending'
+ORDER BY follows.created_at;
+
+-- name: ApproveFollowRequest :execrows
+UPDATE follows SET status = 'approved', created_at = NOW()
+WHERE follower_id = $1 AND followee_id = $2 AND status = 'pending';
+
+-- name: DeleteFollowRequest :execrows
+DELETE FROM follows WHERE follower_id = $1 AND followee_id = $2 AND status = 'pending';
+
+-- name: DeleteFollowsBetween :exec
+DELETE FROM follows
+WHERE (follower_id = @user_id AND followee_id = @other_id)
+	OR (follower_id = @other_id AND followee_id = @user_id);
+
+-- name: ApproveAllFollowRequests :exec
+UPDATE follows SET status = 'approved', created_at = NOW()
+WHERE followee_id = $1 AND status = 'pending';
@@ -0,0 +1,42 @@
+-- name: CreateImportJob :one
+INSERT INTO import_jobs (id, created_at, updated_at, user_id, source, status)
+VALUES (
+	gen_random_uuid(),
+	NOW(),
+	NOW(),
+	$1,
+	$2,
+	'pending'
+)
+RETURNING *;
+
+-- name: GetImportJob :one
+SELECT * FROM import_jobs WHERE id = $1;
+
+-- name: StartImportJob :exec
+UPDATE import_jobs SET status = 'running', total = $2, updated_at = NOW() WHERE id = $1;
+
+-- name: FinishImportJob :exec
+UPDATE import_jobs
+SET status = $2,
+	imported = $3,
+	duplicates = $4,
+	censored = $5,
+	skipped = $6,
+	error = $7,
+	completed_at = NOW(),
+	updated_at = NOW()
+WHERE id = $1;
+
+-- name: CreateImportJobIssue :exec
+INSERT INTO import_job_issues (id, created_at, job_id, source_id, reason)
+VALUES (
+	gen_random_uuid(),
+	NOW(),
+	$1,
+	$2,
+	$3
+);
+
+-- name: GetImportJobIssues :many
+SELECT * FROM import_job_issues WHERE job_id = $1 ORDER BY created_at;
@@ -0,0 +1,64 @@
+-- name: CreateList :one
+INSERT INTO lists (id, created_at, updated_at, user_id, name, private)
+VALUES (
+	gen_random_uuid(),
+	NOW(),
+	NOW(),
+	$1,
+	$2,
+	$3
+)
+RETURNING *;
+
+-- name: GetList :one
+SELECT * FROM lists WHERE id = $1;
+
+-- name: GetListsByUser :many
+SELECT * FROM lists WHERE user_id = $1 ORDER BY created_at;
+
+-- name: CountListsByUser :one
+SELECT COUNT(*) FROM lists WHERE user_id = $1;
+
+-- name: UpdateList :one
+UPDATE lists SET name = $2, private = $3, updated_at = NOW() WHERE id = $1 RETURNING *;
+
+-- name: DeleteList :exec
+DELETE FROM lists WHERE id = $1;
+
+-- name: AddListMember :exec
+INSERT INTO list_members (list_id, user_id, created_at)
+VALUES (
+	$1,
+	$2,
+	NOW()
+)
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveListMember :exec
+DELETE FROM list_members WHERE list_id = $1 AND user_id = $2;
+
+-- name: GetListMembers :many
+SELECT users.id, users.handle, list_members.created_at
+FROM list_members
+JOIN users ON users.id = list_members.user_id
+WHERE list_members.list_id = $1
+ORDER BY list_members.created_at;
+
+-- name: CountListMembers :one
+SELECT COUNT(*) FROM list_members WHERE list_id = $1;
+
+-- name: GetListChirps :many
+SELECT chirps.* FROM chirps
+JOIN list_members ON list_members.user_id = chirps.user_id
+WHERE list_members.list_id = @list_id
+	AND chirps.published_at IS NOT NULL
+	AND (chirps.visibility <> 'unlisted' OR chirps.user_id = @viewer_id)
+	AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, chirps.published_at, @viewer_id::uuid)
+	AND NOT chirp_muted_for(chirps.body_words, chirps.content_warning_words, chirps.user_id, @viewer_id::uuid)
+	AND NOT EXISTS (
+		SELECT 1 FROM mutes WHERE muter_id = @viewer_id AND muted_id = chirps.user_id
+	)
+	AND NOT EXISTS (
+		SELECT 1 FROM blocks WHERE blocker_id = @viewer_id AND blocked_id = chirps.user_id
+	)
+ORDER BY chirps.created_at;
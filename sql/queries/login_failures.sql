@@ -0,0 +1,28 @@
+-- name: GetLoginFailure :one
+SELECT * FROM login_failures WHERE key = $1;
+
+-- name: RecordLoginFailure :one
+INSERT INTO login_failures (key, created_at, updated_at, failures, locked_until)
+VALUES (
+	$1,
+	NOW(),
+	NOW(),
+	1,
+	NULL
+)
+ON CONFLICT (key) DO UPDATE
+SET failures = CASE
+		WHEN login_failures.updated_at < NOW() - INTERVAL '24 hours' THEN 1
+		ELSE login_failures.failures + 1
+	END,
+	updated_at = NOW()
+RETURNING *;
+
+-- name: LockLoginKey :exec
+UPDATE login_failures SET locked_until = $2, updated_at = NOW() WHERE key = $1;
+
+-- name: ClearLoginFailures :exec
+DELETE FROM login_failures WHERE key = $1;
+
+-- name: ResetLoginFailures :exec
+DELETE FROM login_failures;
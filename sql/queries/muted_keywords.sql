@@ -0,0 +1,26 @@
+-- name: CreateMutedKeyword :one
+INSERT INTO muted_keywords (id, user_id, phrase, created_at, expires_at)
+VALUES (
+	gen_random_uuid(),
+	$1,
+	$2,
+	NOW(),
+	$3
+)
+ON CONFLICT (user_id, phrase) DO UPDATE SET expires_at = EXCLUDED.expires_at
+RETURNING *;
+
+-- name: GetMutedKeywordsByUser :many
+SELECT * FROM muted_keywords
+WHERE user_id = $1 AND (expires_at IS NULL OR expires_at > NOW())
+ORDER BY created_at;
+
+-- name: CountMutedKeywordsByUser :one
+SELECT COUNT(*) FROM muted_keywords
+WHERE user_id = $1 AND (expires_at IS NULL OR expires_at > NOW());
+
+-- name: DeleteMutedKeyword :execrows
+DELETE FROM muted_keywords WHERE id = $1 AND user_id = $2;
+
+-- name: DeleteExpiredMutedKeywords :exec
+DELETE FROM muted_keywords WHERE user_id = $1 AND expires_at <= NOW();
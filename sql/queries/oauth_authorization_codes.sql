@@ -0,0 +1,16 @@
+-- name: CreateOAuthAuthorizationCode :one
+INSERT INTO oauth_authorization_codes (code, created_at, client_id, user_id, redirect_uri, scope, code_challenge, expires_at)
+VALUES (
+	$1,
+	NOW(),
+	$2,
+	$3,
+	$4,
+	$5,
+	$6,
+	$7
+)
+RETURNING *;
+
+-- name: ConsumeOAuthAuthorizationCode :one
+DELETE FROM oauth_authorization_codes WHERE code = $1 RETURNING *;
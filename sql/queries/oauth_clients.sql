@@ -0,0 +1,21 @@
+-- name: CreateOAuthClient :one
+INSERT INTO oauth_clients (id, created_at, updated_at, user_id, name, client_secret_hash, redirect_uris)
+VALUES (
+	gen_random_uuid(),
+	NOW(),
+	NOW(),
+	$1,
+	$2,
+	$3,
+	$4
+)
+RETURNING *;
+
+-- name: GetOAuthClient :one
+SELECT * FROM oauth_clients WHERE id = $1;
+
+-- name: GetOAuthClientsByUser :many
+SELECT * FROM oauth_clients WHERE user_id = $1 ORDER BY created_at;
+
+-- name: DeleteOAuthClient :exec
+DELETE FROM oauth_clients WHERE id = $1 AND user_id = $2;
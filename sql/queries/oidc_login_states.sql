@@ -0,0 +1,17 @@
+-- name: CreateOIDCLoginState :one
+INSERT INTO oidc_login_states (state, created_at, provider, nonce, code_verifier, expires_at)
+VALUES (
+	$1,
+	NOW(),
+	$2,
+	$3,
+	$4,
+	$5
+)
+RETURNING *;
+
+-- name: ConsumeOIDCLoginState :one
+DELETE FROM oidc_login_states WHERE state = $1 RETURNING *;
+
+-- name: DeleteExpiredOIDCLoginStates :exec
+DELETE FROM oidc_login_states WHERE expires_at < NOW();
@@ -0,0 +1,47 @@
+-- name: CreatePoll :one
+INSERT INTO polls (id, chirp_id, closes_at)
+VALUES (
+	gen_random_uuid(),
+	$1,
+	$2
+)
+RETURNING *;
+
+-- name: CreatePollOption :one
+INSERT INTO poll_options (id, poll_id, position, label)
+VALUES (
+	gen_random_uuid(),
+	$1,
+	$2,
+	$3
+)
+RETURNING *;
+
+-- name: GetPollByChirp :one
+SELECT * FROM polls WHERE chirp_id = $1;
+
+-- name: GetPollsForChirps :many
+SELECT * FROM polls WHERE chirp_id = ANY(@chirp_ids::uuid[]);
+
+-- name: GetPollOption :one
+SELECT * FROM poll_options WHERE id = $1;
+
+-- name: GetPollResults :many
+SELECT poll_options.id, poll_options.poll_id, poll_options.position, poll_options.label,
+	(SELECT COUNT(*) FROM poll_votes WHERE poll_votes.option_id = poll_options.id) AS votes
+FROM poll_options
+WHERE poll_options.poll_id = ANY(@poll_ids::uuid[])
+ORDER BY poll_options.position;
+
+-- name: GetUserPollVotes :many
+SELECT * FROM poll_votes WHERE user_id = @user_id AND poll_id = ANY(@poll_ids::uuid[]);
+
+-- name: CreatePollVote :execrows
+INSERT INTO poll_votes (poll_id, user_id, option_id, created_at)
+VALUES (
+	$1,
+	$2,
+	$3,
+	NOW()
+)
+ON CONFLICT (poll_id, user_id) DO NOTHING;
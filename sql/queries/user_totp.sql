@@ -0,0 +1,30 @@
+-- name: UpsertUserTOTP :one
+INSERT INTO user_totp (user_id, created_at, updated_at, secret, confirmed_at, last_used_step)
+VALUES (
+	$1,
+	NOW(),
+	NOW(),
+	$2,
+	NULL,
+	0
+)
+ON CONFLICT (user_id) DO UPDATE
+SET secret = EXCLUDED.secret, confirmed_at = NULL, last_used_step = 0, updated_at = NOW()
+RETURNING *;
+
+-- name: GetUserTOTP :one
+SELECT * FROM user_totp WHERE user_id = $1;
+
+-- name: ConfirmUserTOTP :one
+UPDATE user_totp
+SET confirmed_at = NOW(), last_used_step = $2, updated_at = NOW()
+WHERE user_id = $1
+RETURNING *;
+
+-- name: UseTOTPStep :execrows
+UPDATE user_totp
+SET last_used_step = $2, updated_at = NOW()
+WHERE user_id = $1 AND last_used_step < $2;
+
+-- name: DeleteUserTOTP :exec
+DELETE FROM user_totp WHERE user_id = $1;
@@ -19,7 +19,85 @@ SELECT * FROM users WHERE email = $1;
 SELECT * FROM users WHERE id = $1;
 
 -- name: UpdateUser :one
-UPDATE users SET email = $2, hashed_password = $3, updated_at = NOW() WHERE id = $1 RETURNING *;
+UPDATE users
+SET email = $2,
+	hashed_password = $3,
+	email_verified_at = CASE WHEN email = $2 THEN email_verified_at ELSE NULL END,
+	updated_at = NOW()
+WHERE id = $1
+RETURNING *;
 
 -- name: UpgradeUser :one
 UPDATE users SET is_chirpy_red = true, updated_at = NOW() WHERE id = $1 RETURNING *;
+
+-- name: VerifyUserEmail :one
+UPDATE users SET email_verified_at = NOW(), updated_at = NOW() WHERE id = $1 AND email = $2 RETURNING *;
+
+-- name: UpdateUserPassword :exec
+UPDATE users SET hashed_password = $2, updated_at = NOW() WHERE id = $1;
+
+-- name: CreateUserWithVerifiedEmail :one
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, email_verified_at)
+VALUES (
+	gen_random_uuid(),
+	NOW(),
+	NOW(),
+	$1,
+	'unset',
+	NOW()
+)
+RETURNING *;
+
+-- name: ScheduleUserDeletion :one
+UPDATE users SET deletion_scheduled_for = $2, updated_at = NOW() WHERE id = $1 RETURNING *;
+
+-- name: CancelUserDeletion :exec
+UPDATE users SET deletion_scheduled_for = NULL, updated_at = NOW() WHERE id = $1;
+
+-- name: DeleteUsersScheduledForDeletion :many
+DELETE FROM users WHERE deletion_scheduled_for <= NOW()
+RETURNING id, avatar_key, header_key;
+
+-- name: GetUserByHandle :one
+SELECT * FROM users WHERE lower(handle) = lower(@handle);
+
+-- name: UpdateUserProfile :one
+UPDATE users
+SET handle = $2,
+	display_name = $3,
+	bio = $4,
+	location = $5,
+	website = $6,
+	updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: GetUserStats :one
+SELECT
+	(SELECT COUNT(*) FROM follows WHERE followee_id = @user_id AND status = 'approved') AS follower_count,
+	(SELECT COUNT(*) FROM follows WHERE follower_id = @user_id AND status = 'approved') AS following_count,
+	(
+		SELECT COUNT(*) FROM chirps
+		WHERE user_id = @user_id AND published_at IS NOT NULL
+			AND chirp_visible_to(id, user_id, visibility, published_at, @viewer_id::uuid)
+	) AS chirp_count;
+
+-- name: UpdateUserAvatar :one
+UPDATE users SET avatar_key = $2, updated_at = NOW() WHERE id = $1 RETURNING *;
+
+-- name: UpdateUserHeader :one
+UPDATE users SET header_key = $2, updated_at = NOW() WHERE id = $1 RETURNING *;
+
+-- name: GetMentionableUsers :many
+SELECT * FROM users
+WHERE lower(handle) = ANY(@handles::text[])
+	AND id <> @author_id
+	AND NOT EXISTS (
+		SELECT 1 FROM blocks WHERE blocker_id = users.id AND blocked_id = @author_id
+	);
+
+-- name: UpdateUserProtected :one
+UPDATE users SET protected = $2, updated_at = NOW() WHERE id = $1 RETURNING *;
+
+-- name: UpdateUserPinnedChirp :one
+UPDATE users SET pinned_chirp_id = $2, updated_at = NOW() WHERE id = $1 RETURNING *;
@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE email_verifications (
+	token TEXT PRIMARY KEY,
+	created_at TIMESTAMP NOT NULL,
+	user_id UUID NOT NULL REFERENCES users
+		ON DELETE CASCADE,
+	email TEXT NOT NULL,
+	expires_at TIMESTAMP NOT NULL,
+	used_at TIMESTAMP
+);
+
+-- +goose Down
+DROP TABLE email_verifications;
@@ -0,0 +1,11 @@
+-- +goose Up
+CREATE TABLE login_failures (
+	key TEXT PRIMARY KEY,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	failures INTEGER NOT NULL DEFAULT 0,
+	locked_until TIMESTAMP
+);
+
+-- +goose Down
+DROP TABLE login_failures;
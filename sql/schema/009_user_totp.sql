@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE user_totp (
+	user_id UUID PRIMARY KEY REFERENCES users
+		ON DELETE CASCADE,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	secret TEXT NOT NULL,
+	confirmed_at TIMESTAMP,
+	last_used_step BIGINT NOT NULL DEFAULT 0
+);
+
+-- +goose Down
+DROP TABLE user_totp;
@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE user_identities (
+	id UUID PRIMARY KEY,
+	created_at TIMESTAMP NOT NULL,
+	user_id UUID NOT NULL REFERENCES users
+		ON DELETE CASCADE,
+	provider TEXT NOT NULL,
+	subject TEXT NOT NULL,
+	email TEXT NOT NULL,
+	UNIQUE (provider, subject)
+);
+
+-- +goose Down
+DROP TABLE user_identities;
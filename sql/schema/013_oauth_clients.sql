@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE oauth_clients (
+	id UUID PRIMARY KEY,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	user_id UUID NOT NULL REFERENCES users
+		ON DELETE CASCADE,
+	name TEXT NOT NULL,
+	client_secret_hash TEXT,
+	redirect_uris TEXT[] NOT NULL
+);
+
+-- +goose Down
+DROP TABLE oauth_clients;
@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE oauth_authorization_codes (
+	code TEXT PRIMARY KEY,
+	created_at TIMESTAMP NOT NULL,
+	client_id UUID NOT NULL REFERENCES oauth_clients
+		ON DELETE CASCADE,
+	user_id UUID NOT NULL REFERENCES users
+		ON DELETE CASCADE,
+	redirect_uri TEXT NOT NULL,
+	scope TEXT NOT NULL,
+	code_challenge TEXT NOT NULL,
+	expires_at TIMESTAMP NOT NULL
+);
+
+-- +goose Down
+DROP TABLE oauth_authorization_codes;
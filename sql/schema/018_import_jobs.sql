@@ -0,0 +1,30 @@
+-- +goose Up
+CREATE TABLE import_jobs (
+	id UUID PRIMARY KEY,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	user_id UUID NOT NULL REFERENCES users
+		ON DELETE CASCADE,
+	source TEXT NOT NULL,
+	status TEXT NOT NULL DEFAULT 'pending',
+	total INTEGER NOT NULL DEFAULT 0,
+	imported INTEGER NOT NULL DEFAULT 0,
+	duplicates INTEGER NOT NULL DEFAULT 0,
+	censored INTEGER NOT NULL DEFAULT 0,
+	skipped INTEGER NOT NULL DEFAULT 0,
+	error TEXT,
+	completed_at TIMESTAMP
+);
+
+CREATE TABLE import_job_issues (
+	id UUID PRIMARY KEY,
+	created_at TIMESTAMP NOT NULL,
+	job_id UUID NOT NULL REFERENCES import_jobs
+		ON DELETE CASCADE,
+	source_id TEXT NOT NULL,
+	reason TEXT NOT NULL
+);
+
+-- +goose Down
+DROP TABLE import_job_issues;
+DROP TABLE import_jobs;
@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN avatar_key TEXT,
+ADD COLUMN header_key TEXT;
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN avatar_key,
+DROP COLUMN header_key;
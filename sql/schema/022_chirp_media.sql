@@ -0,0 +1,22 @@
+-- +goose Up
+CREATE TABLE chirp_media (
+	id UUID PRIMARY KEY,
+	created_at TIMESTAMP NOT NULL,
+	user_id UUID NOT NULL REFERENCES users
+		ON DELETE CASCADE,
+	chirp_id UUID REFERENCES chirps
+		ON DELETE CASCADE,
+	position INTEGER NOT NULL DEFAULT 0,
+	content_type TEXT NOT NULL,
+	blob_key TEXT NOT NULL,
+	thumbnail_key TEXT NOT NULL,
+	width INTEGER NOT NULL,
+	height INTEGER NOT NULL,
+	alt_text TEXT NOT NULL DEFAULT '',
+	blurhash TEXT NOT NULL
+);
+
+CREATE INDEX chirp_media_chirp_id_idx ON chirp_media (chirp_id, position);
+
+-- +goose Down
+DROP TABLE chirp_media;
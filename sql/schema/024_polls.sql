@@ -0,0 +1,34 @@
+-- +goose Up
+CREATE TABLE polls (
+	id UUID PRIMARY KEY,
+	chirp_id UUID NOT NULL UNIQUE REFERENCES chirps
+		ON DELETE CASCADE,
+	closes_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE poll_options (
+	id UUID PRIMARY KEY,
+	poll_id UUID NOT NULL REFERENCES polls
+		ON DELETE CASCADE,
+	position INTEGER NOT NULL,
+	label TEXT NOT NULL,
+	UNIQUE (poll_id, position)
+);
+
+CREATE TABLE poll_votes (
+	poll_id UUID NOT NULL REFERENCES polls
+		ON DELETE CASCADE,
+	user_id UUID NOT NULL REFERENCES users
+		ON DELETE CASCADE,
+	option_id UUID NOT NULL REFERENCES poll_options
+		ON DELETE CASCADE,
+	created_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (poll_id, user_id)
+);
+
+CREATE INDEX poll_votes_option_id_idx ON poll_votes (option_id);
+
+-- +goose Down
+DROP TABLE poll_votes;
+DROP TABLE poll_options;
+DROP TABLE polls;
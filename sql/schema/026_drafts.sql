@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE drafts (
+	id UUID PRIMARY KEY,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	user_id UUID NOT NULL REFERENCES users
+		ON DELETE CASCADE,
+	body TEXT NOT NULL DEFAULT '',
+	media_ids UUID[] NOT NULL DEFAULT '{}',
+	poll_options TEXT[],
+	poll_closes_at TIMESTAMP,
+	publish_at TIMESTAMP
+);
+
+CREATE INDEX drafts_user_id_idx ON drafts (user_id, updated_at);
+
+-- +goose Down
+DROP TABLE drafts;
@@ -0,0 +1,54 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN visibility TEXT NOT NULL DEFAULT 'public'
+	CHECK (visibility IN ('public', 'unlisted', 'followers', 'mentioned'));
+
+ALTER TABLE drafts
+ADD COLUMN visibility TEXT NOT NULL DEFAULT 'public';
+
+CREATE TABLE chirp_mentions (
+	chirp_id UUID NOT NULL REFERENCES chirps
+		ON DELETE CASCADE,
+	user_id UUID NOT NULL REFERENCES users
+		ON DELETE CASCADE,
+	PRIMARY KEY (chirp_id, user_id)
+);
+
+CREATE INDEX chirp_mentions_user_id_idx ON chirp_mentions (user_id);
+
+-- chirp_visible_to is the one place that decides who can read a chirp. Every query
+-- that returns chirps to a user filters with it so the rules cannot drift apart.
+-- Anonymous readers are passed as the nil UUID, which matches nobody.
+-- +goose StatementBegin
+CREATE FUNCTION chirp_visible_to(chirp_id UUID, author_id UUID, visibility TEXT, published_at TIMESTAMP, viewer_id UUID)
+RETURNS BOOLEAN
+LANGUAGE sql STABLE
+AS $$
+	SELECT author_id = viewer_id OR (
+		published_at IS NOT NULL AND CASE visibility
+			WHEN 'public' THEN TRUE
+			WHEN 'unlisted' THEN TRUE
+			WHEN 'followers' THEN EXISTS (
+				SELECT 1 FROM follows
+				WHERE follows.follower_id = viewer_id AND follows.followee_id = author_id
+			)
+			WHEN 'mentioned' THEN EXISTS (
+				SELECT 1 FROM chirp_mentions
+				WHERE chirp_mentions.chirp_id = chirp_visible_to.chirp_id AND chirp_mentions.user_id = viewer_id
+			)
+			ELSE FALSE
+		END
+	)
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION chirp_visible_to;
+
+DROP TABLE chirp_mentions;
+
+ALTER TABLE drafts
+DROP COLUMN visibility;
+
+ALTER TABLE chirps
+DROP COLUMN visibility;
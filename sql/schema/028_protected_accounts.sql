@@ -0,0 +1,73 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN protected BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Follows of protected accounts start out pending until the account approves them
+ALTER TABLE follows
+ADD COLUMN status TEXT NOT NULL DEFAULT 'approved'
+	CHECK (status IN ('pending', 'approved'));
+
+-- A protected account's chirps are only visible to its approved followers, whatever
+-- each chirp's own visibility is
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION chirp_visible_to(chirp_id UUID, author_id UUID, visibility TEXT, published_at TIMESTAMP, viewer_id UUID)
+RETURNS BOOLEAN
+LANGUAGE sql STABLE
+AS $$
+	SELECT author_id = viewer_id OR (
+		published_at IS NOT NULL
+		AND (
+			NOT (SELECT protected FROM users WHERE users.id = author_id)
+			OR EXISTS (
+				SELECT 1 FROM follows
+				WHERE follows.follower_id = viewer_id AND follows.followee_id = author_id
+					AND follows.status = 'approved'
+			)
+		)
+		AND CASE visibility
+			WHEN 'public' THEN TRUE
+			WHEN 'unlisted' THEN TRUE
+			WHEN 'followers' THEN EXISTS (
+				SELECT 1 FROM follows
+				WHERE follows.follower_id = viewer_id AND follows.followee_id = author_id
+					AND follows.status = 'approved'
+			)
+			WHEN 'mentioned' THEN EXISTS (
+				SELECT 1 FROM chirp_mentions
+				WHERE chirp_mentions.chirp_id = chirp_visible_to.chirp_id AND chirp_mentions.user_id = viewer_id
+			)
+			ELSE FALSE
+		END
+	)
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION chirp_visible_to(chirp_id UUID, author_id UUID, visibility TEXT, published_at TIMESTAMP, viewer_id UUID)
+RETURNS BOOLEAN
+LANGUAGE sql STABLE
+AS $$
+	SELECT author_id = viewer_id OR (
+		published_at IS NOT NULL AND CASE visibility
+			WHEN 'public' THEN TRUE
+			WHEN 'unlisted' THEN TRUE
+			WHEN 'followers' THEN EXISTS (
+				SELECT 1 FROM follows
+				WHERE follows.follower_id = viewer_id AND follows.followee_id = author_id
+			)
+			WHEN 'mentioned' THEN EXISTS (
+				SELECT 1 FROM chirp_mentions
+				WHERE chirp_mentions.chirp_id = chirp_visible_to.chirp_id AND chirp_mentions.user_id = viewer_id
+			)
+			ELSE FALSE
+		END
+	)
+$$;
+-- +goose StatementEnd
+
+ALTER TABLE follows
+DROP COLUMN status;
+
+ALTER TABLE users
+DROP COLUMN protected;
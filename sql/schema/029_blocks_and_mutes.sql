@@ -0,0 +1,98 @@
+-- +goose Up
+CREATE TABLE blocks (
+	blocker_id UUID NOT NULL REFERENCES users
+		ON DELETE CASCADE,
+	blocked_id UUID NOT NULL REFERENCES users
+		ON DELETE CASCADE,
+	created_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (blocker_id, blocked_id),
+	CHECK (blocker_id <> blocked_id)
+);
+
+CREATE INDEX blocks_blocked_id_idx ON blocks (blocked_id);
+
+CREATE TABLE mutes (
+	muter_id UUID NOT NULL REFERENCES users
+		ON DELETE CASCADE,
+	muted_id UUID NOT NULL REFERENCES users
+		ON DELETE CASCADE,
+	created_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (muter_id, muted_id),
+	CHECK (muter_id <> muted_id)
+);
+
+-- Blocked users cannot see any of the blocker's chirps
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION chirp_visible_to(chirp_id UUID, author_id UUID, visibility TEXT, published_at TIMESTAMP, viewer_id UUID)
+RETURNS BOOLEAN
+LANGUAGE sql STABLE
+AS $$
+	SELECT author_id = viewer_id OR (
+		published_at IS NOT NULL
+		AND NOT EXISTS (
+			SELECT 1 FROM blocks
+			WHERE blocks.blocker_id = author_id AND blocks.blocked_id = viewer_id
+		)
+		AND (
+			NOT (SELECT protected FROM users WHERE users.id = author_id)
+			OR EXISTS (
+				SELECT 1 FROM follows
+				WHERE follows.follower_id = viewer_id AND follows.followee_id = author_id
+					AND follows.status = 'approved'
+			)
+		)
+		AND CASE visibility
+			WHEN 'public' THEN TRUE
+			WHEN 'unlisted' THEN TRUE
+			WHEN 'followers' THEN EXISTS (
+				SELECT 1 FROM follows
+				WHERE follows.follower_id = viewer_id AND follows.followee_id = author_id
+					AND follows.status = 'approved'
+			)
+			WHEN 'mentioned' THEN EXISTS (
+				SELECT 1 FROM chirp_mentions
+				WHERE chirp_mentions.chirp_id = chirp_visible_to.chirp_id AND chirp_mentions.user_id = viewer_id
+			)
+			ELSE FALSE
+		END
+	)
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION chirp_visible_to(chirp_id UUID, author_id UUID, visibility TEXT, published_at TIMESTAMP, viewer_id UUID)
+RETURNS BOOLEAN
+LANGUAGE sql STABLE
+AS $$
+	SELECT author_id = viewer_id OR (
+		published_at IS NOT NULL
+		AND (
+			NOT (SELECT protected FROM users WHERE users.id = author_id)
+			OR EXISTS (
+				SELECT 1 FROM follows
+				WHERE follows.follower_id = viewer_id AND follows.followee_id = author_id
+					AND follows.status = 'approved'
+			)
+		)
+		AND CASE visibility
+			WHEN 'public' THEN TRUE
+			WHEN 'unlisted' THEN TRUE
+			WHEN 'followers' THEN EXISTS (
+				SELECT 1 FROM follows
+				WHERE follows.follower_id = viewer_id AND follows.followee_id = author_id
+					AND follows.status = 'approved'
+			)
+			WHEN 'mentioned' THEN EXISTS (
+				SELECT 1 FROM chirp_mentions
+				WHERE chirp_mentions.chirp_id = chirp_visible_to.chirp_id AND chirp_mentions.user_id = viewer_id
+			)
+			ELSE FALSE
+		END
+	)
+$$;
+-- +goose StatementEnd
+
+DROP TABLE mutes;
+
+DROP TABLE blocks;
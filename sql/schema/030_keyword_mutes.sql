@@ -0,0 +1,57 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN content_warning TEXT NOT NULL DEFAULT '',
+ADD COLUMN body_words TEXT NOT NULL DEFAULT '',
+ADD COLUMN content_warning_words TEXT NOT NULL DEFAULT '';
+
+-- body_words and content_warning_words hold the text as normalized by normalizeWords
+-- in the API: lowercase words separated by single spaces. The API fills them in when
+-- a chirp is created; this is the closest SQL equivalent for existing chirps.
+UPDATE chirps SET body_words = lower(array_to_string(array_remove(string_to_array(body, ' '), ''), ' '));
+
+ALTER TABLE drafts
+ADD COLUMN content_warning TEXT NOT NULL DEFAULT '';
+
+-- phrase is normalized the same way as body_words
+CREATE TABLE muted_keywords (
+	id UUID PRIMARY KEY,
+	user_id UUID NOT NULL REFERENCES users
+		ON DELETE CASCADE,
+	phrase TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	expires_at TIMESTAMP,
+	UNIQUE (user_id, phrase)
+);
+
+-- chirp_muted_for is true when a chirp contains one of the viewer's unexpired muted
+-- phrases as whole words, in its body or in its content warning. Viewers never mute
+-- their own chirps.
+-- +goose StatementBegin
+CREATE FUNCTION chirp_muted_for(body_words TEXT, content_warning_words TEXT, author_id UUID, viewer_id UUID)
+RETURNS BOOLEAN
+LANGUAGE sql STABLE
+AS $$
+	SELECT author_id <> viewer_id AND EXISTS (
+		SELECT 1 FROM muted_keywords
+		WHERE muted_keywords.user_id = viewer_id
+			AND (muted_keywords.expires_at IS NULL OR muted_keywords.expires_at > NOW())
+			AND (
+				strpos(' ' || body_words || ' ', ' ' || muted_keywords.phrase || ' ') > 0
+				OR strpos(' ' || content_warning_words || ' ', ' ' || muted_keywords.phrase || ' ') > 0
+			)
+	)
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION chirp_muted_for;
+
+DROP TABLE muted_keywords;
+
+ALTER TABLE drafts
+DROP COLUMN content_warning;
+
+ALTER TABLE chirps
+DROP COLUMN content_warning,
+DROP COLUMN body_words,
+DROP COLUMN content_warning_words;
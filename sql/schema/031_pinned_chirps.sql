@@ -0,0 +1,9 @@
+-- +goose Up
+-- Deleting the pinned chirp unpins it
+ALTER TABLE users
+ADD COLUMN pinned_chirp_id UUID REFERENCES chirps
+	ON DELETE SET NULL;
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN pinned_chirp_id;
@@ -0,0 +1,26 @@
+-- +goose Up
+CREATE TABLE lists (
+	id UUID PRIMARY KEY,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	user_id UUID NOT NULL REFERENCES users
+		ON DELETE CASCADE,
+	name TEXT NOT NULL,
+	private BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE INDEX lists_user_id_idx ON lists (user_id);
+
+CREATE TABLE list_members (
+	list_id UUID NOT NULL REFERENCES lists
+		ON DELETE CASCADE,
+	user_id UUID NOT NULL REFERENCES users
+		ON DELETE CASCADE,
+	created_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (list_id, user_id)
+);
+
+-- +goose Down
+DROP TABLE list_members;
+
+DROP TABLE lists;
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"context"
+
+	"github.com/kmilanbanda/chirpy/internal/database"
+)
+
+// inTx runs fn with queries that share one transaction. The transaction is committed
+// if fn succeeds and rolled back if it returns an error.
+func (cfg *apiConfig) inTx(ctx context.Context, fn func(q *database.Queries) error) error {
+	tx, err := cfg.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(cfg.db.WithTx(tx)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}